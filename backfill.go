@@ -0,0 +1,436 @@
+package gocronometer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultBackfillEmptyWindows is the number of consecutive windows without data after which the search for the first
+// day with data stops unless another is provided on BackfillOptions.
+const DefaultBackfillEmptyWindows = 6
+
+// DefaultBackfillNoDataWindows is the number of consecutive windows without data, walking backwards from the end day,
+// after which the account is considered to have no data unless another is provided on BackfillOptions.
+const DefaultBackfillNoDataWindows = 12
+
+// backfillFloor is the day the search for the first day with data stops at. Cronometer holds no data older than it.
+var backfillFloor = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// BackfillDirection is the order a backfill walks its windows in.
+type BackfillDirection int
+
+const (
+	// BackfillBackward walks from the most recent window to the oldest.
+	BackfillBackward BackfillDirection = iota
+
+	// BackfillForward walks from the oldest window to the most recent.
+	BackfillForward
+)
+
+// BackfillSink receives the data of every window of a backfill.
+type BackfillSink interface {
+	// WriteWindow receives the raw CSV data of the kind for the window. The data must be consumed before returning.
+	// A window may be written again when an interrupted backfill resumes.
+	WriteWindow(ctx context.Context, kind ExportKind, window DateWindow, data io.Reader) error
+}
+
+// BackfillSinkFunc is a function that implements BackfillSink.
+type BackfillSinkFunc func(ctx context.Context, kind ExportKind, window DateWindow, data io.Reader) error
+
+// WriteWindow calls the function.
+func (f BackfillSinkFunc) WriteWindow(ctx context.Context, kind ExportKind, window DateWindow, data io.Reader) error {
+	return f(ctx, kind, window, data)
+}
+
+// DirSink is a BackfillSink that writes every window to its own CSV file named after the window in a directory per
+// kind, such as servings/2021-06-01_2021-06-30.csv. Files are replaced atomically so a rewritten window never leaves
+// a partial file.
+type DirSink struct {
+	Dir string
+}
+
+// WriteWindow writes the data of the window to a temporary file that then replaces the file of the window.
+func (d DirSink) WriteWindow(_ context.Context, kind ExportKind, window DateWindow, data io.Reader) error {
+	dir := filepath.Join(d.Dir, string(kind))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create sink directory: %w", err)
+	}
+	path := filepath.Join(dir, window.Start.Format("2006-01-02")+"_"+window.End.Format("2006-01-02")+".csv")
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary window file: %w", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write window file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary window file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace window file: %w", err)
+	}
+
+	return nil
+}
+
+// BackfillOptions controls a backfill.
+type BackfillOptions struct {
+	// Sink receives the data of every window. It is required.
+	Sink BackfillSink
+
+	// Kinds are the kinds exported for every window. If empty every known kind is exported.
+	Kinds []ExportKind
+
+	// Start is the first day to backfill. If zero the first day with data is detected.
+	Start time.Time
+
+	// End is the last day to backfill. If zero the current day is used.
+	End time.Time
+
+	// Months and Days are the size of each window. If both are zero windows of one month are used.
+	Months int
+	Days   int
+
+	// Direction is the order the windows are walked in.
+	Direction BackfillDirection
+
+	// CheckpointPath is the file the progress of the backfill is recorded in after every window. If the file exists
+	// the backfill it records is resumed. If empty no checkpoint is kept.
+	CheckpointPath string
+
+	// DetectKind is the kind exported to detect the first day with data. If empty ExportKindDailyNutrition is used.
+	DetectKind ExportKind
+
+	// EmptyWindows is the number of consecutive windows without data, walking backwards past the oldest data found,
+	// after which the first day with data is considered found. If zero DefaultBackfillEmptyWindows is used.
+	EmptyWindows int
+
+	// NoDataWindows is the number of consecutive windows without data, walking backwards from the end day, after which
+	// the account is considered to have no data. If zero DefaultBackfillNoDataWindows is used.
+	NoDataWindows int
+}
+
+// BackfillState is the progress of a backfill. It is what the checkpoint file records.
+type BackfillState struct {
+	// Start and End are the first and last days of the backfill. Start is the first day with data if it was
+	// detected. Both are zero if no data was found.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	Direction BackfillDirection `json:"direction"`
+	Kinds     []ExportKind      `json:"kinds"`
+
+	// Months and Days are the size of each window. They are kept so a resumed backfill uses the same windows.
+	Months int `json:"months"`
+	Days   int `json:"days"`
+
+	// Cursor is the day the backfill continues from. Walking forwards every day before it has been written and
+	// walking backwards every day after it has been written.
+	Cursor time.Time `json:"cursor"`
+
+	// Windows is the number of windows written.
+	Windows int `json:"windows"`
+
+	// Complete is true once every window has been written.
+	Complete bool `json:"complete"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// pending returns true if the window has not been written yet.
+func (s *BackfillState) pending(window DateWindow) bool {
+	if s.Direction == BackfillForward {
+		return !window.Start.Before(s.Cursor)
+	}
+	return !window.End.After(s.Cursor)
+}
+
+// advance records the window as written.
+func (s *BackfillState) advance(window DateWindow) {
+	if s.Direction == BackfillForward {
+		s.Cursor = window.End.AddDate(0, 0, 1)
+	} else {
+		s.Cursor = window.Start.AddDate(0, 0, -1)
+	}
+	s.Windows++
+}
+
+// Backfill exports the history of the account in windows and writes every window of every kind to the sink of opts.
+// When no start day is provided the first day with data is detected by exporting DetectKind in windows walking
+// backwards from the end day until EmptyWindows consecutive windows older than the data found hold no data, or until
+// NoDataWindows windows have been exported without finding any. With a checkpoint path the progress is recorded after
+// every window and an interrupted backfill resumes at the first window not written. The state of the backfill is
+// returned even if it fails.
+func (c *Client) Backfill(ctx context.Context, opts BackfillOptions) (*BackfillState, error) {
+	if opts.Sink == nil {
+		return nil, fmt.Errorf("backfill requires a sink")
+	}
+
+	state, err := c.backfillState(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if state.Complete {
+		c.logger().InfoContext(ctx, "backfill already complete", slog.Int("windows", state.Windows))
+		return state, nil
+	}
+
+	// A zero start means no data was found so there is nothing to write.
+	var windows []DateWindow
+	if !state.Start.IsZero() {
+		windows = (ChunkOptions{Months: state.Months, Days: state.Days}).Windows(state.Start, state.End)
+	}
+	if state.Direction == BackfillBackward {
+		slices.Reverse(windows)
+	}
+
+	c.logger().InfoContext(ctx, "backfill started", slog.String("start", state.Start.Format("2006-01-02")),
+		slog.String("end", state.End.Format("2006-01-02")), slog.Int("windows", len(windows)),
+		slog.Int("written", state.Windows))
+
+	for _, window := range windows {
+		if !state.pending(window) {
+			continue
+		}
+
+		for _, kind := range state.Kinds {
+			if err = c.backfillWindow(ctx, opts.Sink, kind, window); err != nil {
+				return state, fmt.Errorf("backfill of %s for %s failed: %w", kind, window, err)
+			}
+		}
+
+		state.advance(window)
+		if err = saveBackfillState(opts.CheckpointPath, state); err != nil {
+			return state, err
+		}
+		c.logger().DebugContext(ctx, "backfill window written", slog.String("window", window.String()))
+		reportProgress(ctx, Progress{Phase: PhaseWindowDone, Window: window, WindowsCompleted: state.Windows,
+			WindowsTotal: len(windows)})
+	}
+
+	state.Complete = true
+	if err = saveBackfillState(opts.CheckpointPath, state); err != nil {
+		return state, err
+	}
+	c.logger().InfoContext(ctx, "backfill finished", slog.Int("windows", state.Windows))
+
+	return state, nil
+}
+
+// backfillState returns the state recorded in the checkpoint of opts or a new state if there is none. The first day
+// with data is detected for a new state without a start day.
+func (c *Client) backfillState(ctx context.Context, opts BackfillOptions) (*BackfillState, error) {
+	if opts.CheckpointPath != "" {
+		state, err := loadBackfillState(opts.CheckpointPath)
+		if err == nil {
+			c.logger().InfoContext(ctx, "backfill resumed", slog.String("cursor", state.Cursor.Format("2006-01-02")))
+			return state, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	state := &BackfillState{
+		Start:     opts.Start,
+		End:       opts.End,
+		Direction: opts.Direction,
+		Kinds:     opts.Kinds,
+		Months:    opts.Months,
+		Days:      opts.Days,
+	}
+	if state.End.IsZero() {
+		state.End = time.Now()
+	}
+	state.End = startOfDay(state.End, state.End.Location())
+	if len(state.Kinds) == 0 {
+		state.Kinds = exportKinds
+	}
+
+	if state.Start.IsZero() {
+		first, err := c.detectFirstDay(ctx, opts, state.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect first day with data: %w", err)
+		}
+		state.Start = first
+	}
+	if !state.Start.IsZero() {
+		state.Start = startOfDay(state.Start, state.End.Location())
+	}
+
+	if state.Direction == BackfillForward {
+		state.Cursor = state.Start
+	} else {
+		state.Cursor = state.End
+	}
+
+	// A new state is recorded right away so the detection is not repeated when resuming.
+	if err := saveBackfillState(opts.CheckpointPath, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// backfillWindow streams the export of the kind for the window into the sink.
+func (c *Client) backfillWindow(ctx context.Context, sink BackfillSink, kind ExportKind, window DateWindow) error {
+	body, err := c.ExportStream(ctx, kind, window.Start, window.End)
+	if err != nil {
+		return err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
+
+	return sink.WriteWindow(ctx, kind, window, body)
+}
+
+// detectFirstDay returns the first day with data of the DetectKind of opts on or before end. Windows are exported
+// walking backwards until EmptyWindows consecutive windows older than the data found hold no data or backfillFloor is
+// reached. A zero time is returned if the NoDataWindows most recent windows hold no data. Every window is one export,
+// so detection costs NoDataWindows exports for an account without data and one export per window of history plus
+// EmptyWindows otherwise.
+func (c *Client) detectFirstDay(ctx context.Context, opts BackfillOptions, end time.Time) (time.Time, error) {
+	kind := opts.DetectKind
+	if kind == "" {
+		kind = ExportKindDailyNutrition
+	}
+	emptyWindows := opts.EmptyWindows
+	if emptyWindows < 1 {
+		emptyWindows = DefaultBackfillEmptyWindows
+	}
+	noDataWindows := opts.NoDataWindows
+	if noDataWindows < 1 {
+		noDataWindows = DefaultBackfillNoDataWindows
+	}
+	months, days := (ChunkOptions{Months: opts.Months, Days: opts.Days}).size()
+
+	var first time.Time
+	windows := 0
+	windowEnd := end
+	for empty := 0; empty < emptyWindows && !windowEnd.Before(backfillFloor); {
+		windowStart := windowEnd.AddDate(0, -months, -days).AddDate(0, 0, 1)
+
+		data, err := c.Export(ctx, kind, windowStart, windowEnd)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		earliest, err := earliestDay(data, end.Location())
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() {
+			// Only windows older than the data found so far count towards the end of the history.
+			if !first.IsZero() {
+				empty++
+			}
+		} else {
+			first, empty = earliest, 0
+		}
+
+		windowEnd = windowStart.AddDate(0, 0, -1)
+
+		// Without data in the most recent windows the account is considered to have none.
+		windows++
+		if first.IsZero() && windows == noDataWindows {
+			break
+		}
+	}
+
+	c.logger().InfoContext(ctx, "backfill first day detected", slog.String("kind", string(kind)),
+		slog.String("day", first.Format("2006-01-02")))
+
+	return first, nil
+}
+
+// earliestDay returns the earliest day in the first column of the CSV data or a zero time if it has no rows.
+func earliestDay(data string, location *time.Location) (time.Time, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	var earliest time.Time
+	for line := 0; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: failed to read export: %w", ErrUnexpectedResponse, err)
+		}
+		if line == 0 || len(record) == 0 || record[0] == "" {
+			continue
+		}
+
+		day, err := time.ParseInLocation("2006-01-02", record[0], location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: failed to parse day of export: %w", ErrUnexpectedResponse, err)
+		}
+		if earliest.IsZero() || day.Before(earliest) {
+			earliest = day
+		}
+	}
+
+	return earliest, nil
+}
+
+// loadBackfillState reads the state from the checkpoint file.
+func loadBackfillState(path string) (*BackfillState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	var state BackfillState
+	if err = json.NewDecoder(file).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode backfill checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// saveBackfillState writes the state to a temporary file that then replaces the checkpoint file. Nothing is written
+// if path is empty.
+func saveBackfillState(path string, state *BackfillState) error {
+	if path == "" {
+		return nil
+	}
+	state.UpdatedAt = time.Now()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary backfill checkpoint: %w", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer os.Remove(tmp.Name())
+
+	if err = json.NewEncoder(tmp).Encode(state); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to encode backfill checkpoint: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary backfill checkpoint: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace backfill checkpoint: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// memorySink is a BackfillSink that records every window written to it and fails the windows in failOn.
+type memorySink struct {
+	mu      sync.Mutex
+	windows map[gocronometer.ExportKind][]string
+	data    map[gocronometer.ExportKind]string
+	failOn  map[string]bool
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{
+		windows: make(map[gocronometer.ExportKind][]string),
+		data:    make(map[gocronometer.ExportKind]string),
+		failOn:  make(map[string]bool),
+	}
+}
+
+func (s *memorySink) WriteWindow(_ context.Context, kind gocronometer.ExportKind, window gocronometer.DateWindow, data io.Reader) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failOn[window.String()] {
+		return errors.New("sink unavailable")
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.windows[kind] = append(s.windows[kind], window.String())
+	s.data[kind] += string(b)
+	return nil
+}
+
+func TestClient_Backfill_DetectsFirstDay(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+	sink := newMemorySink()
+
+	state, err := client.Backfill(context.Background(), gocronometer.BackfillOptions{
+		Sink:         sink,
+		Kinds:        []gocronometer.ExportKind{gocronometer.ExportKindServings, gocronometer.ExportKindNotes},
+		End:          time.Date(2021, 8, 20, 0, 0, 0, 0, time.UTC),
+		EmptyWindows: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to backfill: %s", err)
+	}
+
+	if !state.Complete || state.Start.Format("2006-01-02") != "2021-06-02" {
+		t.Fatalf("expected a complete backfill from the first seeded day but found %+v", state)
+	}
+
+	// Walking backwards the most recent window is written first.
+	expected := []string{"2021-08-02 to 2021-08-20", "2021-07-02 to 2021-08-01", "2021-06-02 to 2021-07-01"}
+	if got := strings.Join(sink.windows[gocronometer.ExportKindServings], ","); got != strings.Join(expected, ",") {
+		t.Fatalf("expected windows %v but found %s", expected, got)
+	}
+	if !strings.Contains(sink.data[gocronometer.ExportKindServings], "Oatmeal") ||
+		!strings.Contains(sink.data[gocronometer.ExportKindNotes], "Felt great") {
+		t.Fatalf("expected the seeded data to be written but found %v", sink.data)
+	}
+}
+
+func TestClient_Backfill_NoData(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	sink := newMemorySink()
+
+	// The fake server has no data before June 2021.
+	state, err := client.Backfill(context.Background(), gocronometer.BackfillOptions{
+		Sink:         sink,
+		End:          time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC),
+		EmptyWindows: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to backfill: %s", err)
+	}
+
+	if !state.Complete || !state.Start.IsZero() || len(sink.windows) != 0 {
+		t.Fatalf("expected an empty complete backfill but found %+v and %v", state, sink.windows)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != gocronometer.DefaultBackfillNoDataWindows {
+		t.Fatalf("expected detection to stop after %d exports but found %d", gocronometer.DefaultBackfillNoDataWindows, n)
+	}
+}
+
+func TestClient_Backfill_NoDataWindows(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	// Data older than the windows searched is not found.
+	state, err := client.Backfill(context.Background(), gocronometer.BackfillOptions{
+		Sink:          newMemorySink(),
+		End:           time.Date(2021, 9, 30, 0, 0, 0, 0, time.UTC),
+		NoDataWindows: 3,
+	})
+	if err != nil {
+		t.Fatalf("failed to backfill: %s", err)
+	}
+
+	if !state.Start.IsZero() {
+		t.Fatalf("expected no data to be found but found %s", state.Start)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 3 {
+		t.Fatalf("expected detection to stop after 3 exports but found %d", n)
+	}
+}
+
+func TestClient_Backfill_Resume(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	checkpoint := filepath.Join(t.TempDir(), "backfill.json")
+
+	sink := newMemorySink()
+	sink.failOn["2021-06-11 to 2021-06-20"] = true
+
+	opts := gocronometer.BackfillOptions{
+		Sink:           sink,
+		Kinds:          []gocronometer.ExportKind{gocronometer.ExportKindServings},
+		Start:          time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:            time.Date(2021, 6, 30, 0, 0, 0, 0, time.UTC),
+		Days:           10,
+		Direction:      gocronometer.BackfillForward,
+		CheckpointPath: checkpoint,
+	}
+
+	state, err := client.Backfill(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "2021-06-11 to 2021-06-20") {
+		t.Fatalf("expected the second window to fail but received %v", err)
+	}
+	if state.Windows != 1 || state.Cursor.Format("2006-01-02") != "2021-06-11" {
+		t.Fatalf("expected the first window to be recorded but found %+v", state)
+	}
+
+	// Resuming with a working sink only exports the windows that were not written.
+	delete(sink.failOn, "2021-06-11 to 2021-06-20")
+	exports := srv.RequestCount(gocronometertest.EndpointExport)
+
+	state, err = client.Backfill(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("failed to resume backfill: %s", err)
+	}
+	if !state.Complete || state.Windows != 3 {
+		t.Fatalf("expected a complete backfill of 3 windows but found %+v", state)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport) - exports; n != 2 {
+		t.Fatalf("expected 2 exports when resuming but found %d", n)
+	}
+
+	expected := "2021-06-01 to 2021-06-10,2021-06-11 to 2021-06-20,2021-06-21 to 2021-06-30"
+	if got := strings.Join(sink.windows[gocronometer.ExportKindServings], ","); got != expected {
+		t.Fatalf("expected windows %s but found %s", expected, got)
+	}
+
+	// A complete backfill is not repeated.
+	if _, err = client.Backfill(context.Background(), opts); err != nil {
+		t.Fatalf("failed to rerun backfill: %s", err)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport) - exports; n != 2 {
+		t.Fatalf("expected no exports for a complete backfill but found %d", n-2)
+	}
+}
+
+func TestDirSink(t *testing.T) {
+	dir := t.TempDir()
+	window := gocronometer.DateWindow{
+		Start: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2021, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	sink := gocronometer.DirSink{Dir: dir}
+	if err := sink.WriteWindow(context.Background(), gocronometer.ExportKindNotes, window, strings.NewReader("Day,Group,Note\n")); err != nil {
+		t.Fatalf("failed to write window: %s", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "notes", "2021-06-01_2021-06-30.csv"))
+	if err != nil {
+		t.Fatalf("failed to read window file: %s", err)
+	}
+	if string(data) != "Day,Group,Note\n" {
+		t.Fatalf("expected the window data but found %s", data)
+	}
+}
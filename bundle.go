@@ -0,0 +1,161 @@
+package gocronometer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExportConcurrency is the number of exports ExportAll fetches at once unless another is provided on
+// ClientOptions.
+const DefaultExportConcurrency = 2
+
+// exportKinds are the export kinds known to the library in the order ExportAll fetches them.
+var exportKinds = []ExportKind{
+	ExportKindDailyNutrition,
+	ExportKindServings,
+	ExportKindExercises,
+	ExportKindBiometrics,
+	ExportKindNotes,
+}
+
+// ExportBundle is the data of every export kind for a date range. The raw CSV data of every kind that succeeded is
+// available in Raw and the kinds with a parser are parsed with recorded times in UTC. The raw data can be parsed again
+// with another location.
+type ExportBundle struct {
+	Start time.Time
+	End   time.Time
+
+	// Raw is the raw CSV data of each kind that was exported.
+	Raw map[ExportKind]string
+
+	Servings   ServingRecords
+	Exercises  ExerciseRecords
+	Biometrics BiometricRecords
+
+	// Errors is the reason each failed kind failed. A kind whose data was exported but could not be parsed has both
+	// raw data and an error.
+	Errors map[ExportKind]error
+}
+
+// Err returns the errors of the failed kinds joined in export order or nil if every kind succeeded.
+func (b *ExportBundle) Err() error {
+	errs := make([]error, 0, len(b.Errors))
+	for _, kind := range exportKinds {
+		if err, ok := b.Errors[kind]; ok {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exportConcurrency returns the number of exports ExportAll fetches at once.
+func (c *Client) exportConcurrency() int {
+	if c.ExportConcurrency < 1 {
+		return DefaultExportConcurrency
+	}
+	return c.ExportConcurrency
+}
+
+// ExportAll exports every kind within the date range into a bundle. Only the YYYY-mm-dd is utilized of startDate and
+// endDate. The kinds are fetched concurrently, up to the ExportConcurrency of the client at once, with a single export
+// token. A failing kind does not prevent the others from being exported. The bundle is always returned and the error
+// is that of Err.
+func (c *Client) ExportAll(ctx context.Context, startDate time.Time, endDate time.Time) (*ExportBundle, error) {
+	bundle := &ExportBundle{
+		Start:  startDate,
+		End:    endDate,
+		Raw:    make(map[ExportKind]string),
+		Errors: make(map[ExportKind]error),
+	}
+
+	c.logger().DebugContext(ctx, "export all started", slog.String("start", startDate.Format("2006-01-02")),
+		slog.String("end", endDate.Format("2006-01-02")), slog.Int("concurrency", c.exportConcurrency()))
+
+	// Obtaining the token up front lets every export share it rather than racing to generate their own.
+	err := c.withSessionRenewal(ctx, func() error {
+		_, err := c.exportToken(ctx)
+		return err
+	})
+	if err != nil {
+		for _, kind := range exportKinds {
+			bundle.Errors[kind] = fmt.Errorf("failed to get token to make request: %w", err)
+		}
+		return bundle, bundle.Err()
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, c.exportConcurrency())
+	)
+
+	for _, kind := range exportKinds {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			data, err := c.Export(ctx, kind, startDate, endDate)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				bundle.Errors[kind] = err
+				return
+			}
+			bundle.Raw[kind] = data
+		}()
+	}
+	wg.Wait()
+
+	// Parsing the kinds that have a parser.
+	if err := bundle.parse(ctx); err != nil {
+		c.logger().WarnContext(ctx, "export all parsing failed", c.errAttr(err))
+	}
+
+	if err := bundle.Err(); err != nil {
+		c.logger().ErrorContext(ctx, "export all incomplete", slog.Int("failed", len(bundle.Errors)), c.errAttr(err))
+		return bundle, err
+	}
+
+	c.logger().InfoContext(ctx, "export all finished")
+
+	return bundle, nil
+}
+
+// parse parses the raw data of the kinds that have a parser. Parsing errors are recorded in Errors and returned
+// joined. Progress is reported to the progress function carried by ctx.
+func (b *ExportBundle) parse(ctx context.Context) error {
+	window := DateWindow{Start: b.Start, End: b.End}
+
+	var errs []error
+	record := func(kind ExportKind, err error) {
+		if err != nil {
+			err = fmt.Errorf("parsing %s export: %w", kind, err)
+			b.Errors[kind] = err
+			errs = append(errs, err)
+		}
+	}
+
+	var err error
+	if raw, ok := b.Raw[ExportKindServings]; ok {
+		b.Servings, err = parseServingsExport(strings.NewReader(raw), time.UTC, parseProgress(ctx, ExportKindServings, window))
+		record(ExportKindServings, err)
+	}
+	if raw, ok := b.Raw[ExportKindExercises]; ok {
+		b.Exercises, err = parseExerciseExport(strings.NewReader(raw), time.UTC, parseProgress(ctx, ExportKindExercises, window))
+		record(ExportKindExercises, err)
+	}
+	if raw, ok := b.Raw[ExportKindBiometrics]; ok {
+		b.Biometrics, err = parseBiometricRecordsExport(strings.NewReader(raw), time.UTC, parseProgress(ctx, ExportKindBiometrics, window))
+		record(ExportKindBiometrics, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_ExportAll(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	bundle, err := client.ExportAll(context.Background(), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to export all: %s", err)
+	}
+
+	for _, kind := range []gocronometer.ExportKind{gocronometer.ExportKindDailyNutrition, gocronometer.ExportKindServings,
+		gocronometer.ExportKindExercises, gocronometer.ExportKindBiometrics, gocronometer.ExportKindNotes} {
+		if !strings.Contains(bundle.Raw[kind], "\n") {
+			t.Fatalf("expected raw data for %s but found [%s]", kind, bundle.Raw[kind])
+		}
+	}
+
+	if len(bundle.Servings) != 2 || len(bundle.Exercises) != 1 || len(bundle.Biometrics) != 1 {
+		t.Fatalf("expected the seeded records to be parsed but found %d servings, %d exercises and %d biometrics",
+			len(bundle.Servings), len(bundle.Exercises), len(bundle.Biometrics))
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 1 {
+		t.Fatalf("expected 1 token to be generated but found %d", n)
+	}
+}
+
+func TestClient_ExportAll_PartialFailure(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Fetching one kind at a time makes the daily nutrition export the one that fails.
+	opts := srv.ClientOptions()
+	opts.ExportConcurrency = 1
+	client := gocronometer.NewClient(opts)
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 1)
+
+	bundle, err := client.ExportAll(context.Background(), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC))
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a 500 StatusError but received %v", err)
+	}
+
+	if len(bundle.Errors) != 1 || bundle.Errors[gocronometer.ExportKindDailyNutrition] == nil {
+		t.Fatalf("expected only the daily nutrition export to fail but found %v", bundle.Errors)
+	}
+	if _, ok := bundle.Raw[gocronometer.ExportKindDailyNutrition]; ok {
+		t.Fatalf("expected no raw data for the failed kind")
+	}
+	if len(bundle.Raw) != 4 || len(bundle.Servings) != 2 {
+		t.Fatalf("expected the other kinds to be exported but found %d raw kinds and %d servings",
+			len(bundle.Raw), len(bundle.Servings))
+	}
+}
+
+func TestClient_ExportAll_Concurrency(t *testing.T) {
+	srv := newTestServer(t)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	opts := srv.ClientOptions()
+	opts.ExportConcurrency = 3
+	opts.Hooks = []gocronometer.Hooks{{
+		BeforeRequest: func(ctx context.Context, op string, req *http.Request) {
+			if !strings.HasPrefix(op, gocronometer.OpExportPrefix) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight++
+			peak = max(peak, inFlight)
+		},
+		AfterResponse: func(ctx context.Context, op string, req *http.Request, resp *http.Response, elapsed time.Duration) {
+			if !strings.HasPrefix(op, gocronometer.OpExportPrefix) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight--
+		},
+	}}
+	client := gocronometer.NewClient(opts)
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	if _, err := client.ExportAll(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export all: %s", err)
+	}
+
+	if peak > 3 {
+		t.Fatalf("expected at most 3 exports at once but found %d", peak)
+	}
+}
+
+func TestClient_ExportAll_TokenFailure(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.ExpireSessions()
+
+	bundle, err := client.ExportAll(context.Background(), time.Now(), time.Now())
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired but received %v", err)
+	}
+
+	if len(bundle.Errors) != 5 || len(bundle.Raw) != 0 {
+		t.Fatalf("expected every kind to fail but found %d errors and %d raw kinds", len(bundle.Errors), len(bundle.Raw))
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 0 {
+		t.Fatalf("expected no export requests without a token but found %d", n)
+	}
+}
@@ -0,0 +1,236 @@
+package gocronometer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChunkOptions controls how a chunked export splits its date range into windows and how the windows are fetched.
+type ChunkOptions struct {
+	// Months and Days are the size of each window. Windows start at the start date and each following window starts
+	// Months and Days after the previous one. If both are zero windows of one month are used.
+	Months int
+	Days   int
+
+	// Concurrency is the number of windows fetched at once. Values below 2 fetch the windows sequentially.
+	Concurrency int
+}
+
+// DateWindow is a range of days of an export. Both the Start and End days are included.
+type DateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String returns the window in the YYYY-mm-dd form used by the export API.
+func (w DateWindow) String() string {
+	return w.Start.Format("2006-01-02") + " to " + w.End.Format("2006-01-02")
+}
+
+// size returns the months and days of each window.
+func (o ChunkOptions) size() (months int, days int) {
+	months, days = max(o.Months, 0), max(o.Days, 0)
+	if months == 0 && days == 0 {
+		months = 1
+	}
+	return months, days
+}
+
+// Windows splits the days from startDate to endDate into the windows of the options in date order.
+func (o ChunkOptions) Windows(startDate time.Time, endDate time.Time) []DateWindow {
+	months, days := o.size()
+
+	start := startOfDay(startDate, startDate.Location())
+	end := startOfDay(endDate, startDate.Location())
+
+	windows := make([]DateWindow, 0)
+	for !start.After(end) {
+		next := start.AddDate(0, months, days)
+		windowEnd := next.AddDate(0, 0, -1)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		windows = append(windows, DateWindow{Start: start, End: windowEnd})
+		start = next
+	}
+
+	return windows
+}
+
+// startOfDay returns midnight of the day of t in the location provided.
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// ExportChunked exports the data of the kind provided within the date range by splitting it into the windows of opts.
+// The CSV data of the windows is concatenated in date order with a single header. If any window fails the data of the
+// windows that succeeded is returned along with a ChunkError listing the windows that failed.
+func (c *Client) ExportChunked(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time, opts ChunkOptions) (string, error) {
+	windows := opts.Windows(startDate, endDate)
+	parts := make([]string, len(windows))
+
+	err := c.exportWindows(ctx, kind, windows, opts.Concurrency, func(i int, body io.Reader) error {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		parts[i] = string(data)
+		return nil
+	})
+
+	return mergeCSV(parts), err
+}
+
+// ExportServingsChunked exports the servings within the date range by splitting it into the windows of opts and
+// parsing each window with the location provided. The records are merged in date order. If any window fails the
+// records of the windows that succeeded are returned along with a ChunkError listing the windows that failed.
+func (c *Client) ExportServingsChunked(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location, opts ChunkOptions) (ServingRecords, error) {
+	windows := opts.Windows(startDate, endDate)
+	parts := make([]ServingRecords, len(windows))
+
+	err := c.exportWindows(ctx, ExportKindServings, windows, opts.Concurrency, func(i int, body io.Reader) (err error) {
+		parts[i], err = parseServingsExport(body, location, parseProgress(ctx, ExportKindServings, windows[i]))
+		return err
+	})
+
+	records := make(ServingRecords, 0)
+	for _, part := range parts {
+		records = append(records, part...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].RecordedTime.Before(records[j].RecordedTime) })
+
+	return records, err
+}
+
+// ExportExercisesChunked exports the exercises within the date range by splitting it into the windows of opts and
+// parsing each window with the location provided. The records are merged in date order. If any window fails the
+// records of the windows that succeeded are returned along with a ChunkError listing the windows that failed.
+func (c *Client) ExportExercisesChunked(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location, opts ChunkOptions) (ExerciseRecords, error) {
+	windows := opts.Windows(startDate, endDate)
+	parts := make([]ExerciseRecords, len(windows))
+
+	err := c.exportWindows(ctx, ExportKindExercises, windows, opts.Concurrency, func(i int, body io.Reader) (err error) {
+		parts[i], err = parseExerciseExport(body, location, parseProgress(ctx, ExportKindExercises, windows[i]))
+		return err
+	})
+
+	records := make(ExerciseRecords, 0)
+	for _, part := range parts {
+		records = append(records, part...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].RecordedTime.Before(records[j].RecordedTime) })
+
+	return records, err
+}
+
+// ExportBiometricsChunked exports the biometric records within the date range by splitting it into the windows of
+// opts and parsing each window with the location provided. The records are merged in date order. If any window fails
+// the records of the windows that succeeded are returned along with a ChunkError listing the windows that failed.
+func (c *Client) ExportBiometricsChunked(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location, opts ChunkOptions) (BiometricRecords, error) {
+	windows := opts.Windows(startDate, endDate)
+	parts := make([]BiometricRecords, len(windows))
+
+	err := c.exportWindows(ctx, ExportKindBiometrics, windows, opts.Concurrency, func(i int, body io.Reader) (err error) {
+		parts[i], err = parseBiometricRecordsExport(body, location, parseProgress(ctx, ExportKindBiometrics, windows[i]))
+		return err
+	})
+
+	records := make(BiometricRecords, 0)
+	for _, part := range parts {
+		records = append(records, part...)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].RecordedTime.Before(records[j].RecordedTime) })
+
+	return records, err
+}
+
+// exportWindows streams the export of the kind provided for every window and passes the body to handle along with the
+// index of the window. Up to concurrency windows are fetched at once and handle may be called concurrently for
+// different windows. A ChunkError is returned if any window fails.
+func (c *Client) exportWindows(ctx context.Context, kind ExportKind, windows []DateWindow, concurrency int,
+	handle func(i int, body io.Reader) error) error {
+
+	concurrency = max(concurrency, 1)
+	c.logger().DebugContext(ctx, "chunked export started", slog.String("kind", string(kind)),
+		slog.Int("windows", len(windows)), slog.Int("concurrency", concurrency))
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		failed    []WindowError
+		completed int
+		sem       = make(chan struct{}, concurrency)
+	)
+
+	for i, window := range windows {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := c.exportWindow(ctx, kind, window, func(body io.Reader) error { return handle(i, body) })
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed = append(failed, WindowError{Window: window, Err: err})
+			}
+			completed++
+			reportProgress(ctx, Progress{Phase: PhaseWindowDone, Kind: kind, Window: window,
+				WindowsCompleted: completed, WindowsTotal: len(windows)})
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Window.Start.Before(failed[j].Window.Start) })
+	c.logger().ErrorContext(ctx, "chunked export incomplete", slog.String("kind", string(kind)),
+		slog.Int("windows", len(windows)), slog.Int("failed", len(failed)))
+
+	return &ChunkError{Kind: kind, Total: len(windows), Failed: failed}
+}
+
+// exportWindow streams the export of the kind provided for the window and passes the body to handle.
+func (c *Client) exportWindow(ctx context.Context, kind ExportKind, window DateWindow, handle func(body io.Reader) error) error {
+	body, err := c.ExportStream(ctx, kind, window.Start, window.End)
+	if err != nil {
+		return err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
+
+	return handle(body)
+}
+
+// mergeCSV concatenates the CSV data provided keeping only the header of the first non empty part.
+func mergeCSV(parts []string) string {
+	var b strings.Builder
+	header := false
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if header {
+			_, part, _ = strings.Cut(part, "\n")
+		}
+		header = true
+
+		if part == "" {
+			continue
+		}
+		b.WriteString(part)
+		if !strings.HasSuffix(part, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestChunkOptions_Windows(t *testing.T) {
+	start := time.Date(2021, 1, 15, 13, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	windows := gocronometer.ChunkOptions{}.Windows(start, end)
+	expected := []string{"2021-01-15 to 2021-02-14", "2021-02-15 to 2021-03-14", "2021-03-15 to 2021-03-20"}
+	if len(windows) != len(expected) {
+		t.Fatalf("expected %d monthly windows but found %v", len(expected), windows)
+	}
+	for i, w := range windows {
+		if w.String() != expected[i] {
+			t.Fatalf("expected window %d to be %s but found %s", i, expected[i], w)
+		}
+	}
+
+	if windows := (gocronometer.ChunkOptions{Days: 7}).Windows(start, start.AddDate(0, 0, 13)); len(windows) != 2 {
+		t.Fatalf("expected 2 weekly windows but found %v", windows)
+	}
+
+	if windows := (gocronometer.ChunkOptions{}).Windows(end, start); len(windows) != 0 {
+		t.Fatalf("expected no windows for a reversed range but found %v", windows)
+	}
+}
+
+func TestClient_ExportChunked(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	expected, err := client.ExportServings(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("failed to export servings: %s", err)
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		data, err := client.ExportChunked(context.Background(), gocronometer.ExportKindServings, start, end,
+			gocronometer.ChunkOptions{Days: 1, Concurrency: concurrency})
+		if err != nil {
+			t.Fatalf("failed to export servings in chunks: %s", err)
+		}
+
+		if data != expected {
+			t.Fatalf("expected the merged export to match the single export\n%s\nbut found\n%s", expected, data)
+		}
+		if n := strings.Count(data, "Food Name"); n != 1 {
+			t.Fatalf("expected a single header but found %d", n)
+		}
+	}
+}
+
+func TestClient_ExportServingsChunked(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	servings, err := client.ExportServingsChunked(context.Background(), start, end, time.UTC,
+		gocronometer.ChunkOptions{Days: 2, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("failed to export servings in chunks: %s", err)
+	}
+
+	if len(servings) != 2 || servings[0].FoodName != "Oatmeal" || servings[1].FoodName != "Apple" {
+		t.Fatalf("expected the seeded servings in date order but found %+v", servings)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 5 {
+		t.Fatalf("expected 5 window exports but found %d", n)
+	}
+}
+
+func TestClient_ExportChunked_PartialFailure(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 1)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	// The first window fetched fails, which is the first in date order when fetched sequentially.
+	exercises, err := client.ExportExercisesChunked(context.Background(), start, end, time.UTC,
+		gocronometer.ChunkOptions{Days: 2})
+
+	var chunkErr *gocronometer.ChunkError
+	if !errors.As(err, &chunkErr) {
+		t.Fatalf("expected a ChunkError but received %v", err)
+	}
+	if chunkErr.Total != 2 || len(chunkErr.Failed) != 1 || chunkErr.Failed[0].Window.String() != "2021-06-01 to 2021-06-02" {
+		t.Fatalf("expected the first of 2 windows to fail but found %s", chunkErr)
+	}
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the window error to be a 500 StatusError but received %v", err)
+	}
+
+	// The seeded exercise falls within the failed window so nothing was recovered.
+	if len(exercises) != 0 {
+		t.Fatalf("expected no exercises from the failed window but found %+v", exercises)
+	}
+
+	// Retrying the failed windows recovers the data.
+	for _, window := range chunkErr.Windows() {
+		exercises, err = client.ExportExercisesChunked(context.Background(), window.Start, window.End, time.UTC,
+			gocronometer.ChunkOptions{Days: 2})
+		if err != nil {
+			t.Fatalf("failed to retry window %s: %s", window, err)
+		}
+	}
+	if len(exercises) != 1 {
+		t.Fatalf("expected the seeded exercise after the retry but found %+v", exercises)
+	}
+}
@@ -0,0 +1,239 @@
+package gocronometer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// GWTValues are the GWT magic values that change with every release of the web app.
+type GWTValues struct {
+	// Permutation is the strong name of the permutation of the web app, provided in the x-gwt-permutation header.
+	Permutation string
+
+	// Header is the strong name of the serialization policy of the service, provided at the start of every GWT
+	// request body.
+	Header string
+}
+
+// DiscoveryUserAgents are the GWT user.agent property values in order of preference when selecting the permutation
+// of the web app.
+var DiscoveryUserAgents = []string{"safari", "gecko1_8"}
+
+// gwtPermutationRegex matches the mapping of property values to a permutation strong name in the nocache.js bootstrap.
+// The obfuscated bootstrap moves string literals into variables, so the values and the strong name may be either.
+var gwtPermutationRegex = regexp.MustCompile(`\[((?:\s*(?:'[\w.-]+'|"[\w.-]+"|[\w$]+)\s*,?)+)\]\s*,\s*('[0-9A-F]{32}'|"[0-9A-F]{32}"|[\w$]+)`)
+
+// gwtStringVarRegex matches the assignment of a string literal to a variable.
+var gwtStringVarRegex = regexp.MustCompile(`([\w$]+)\s*=\s*(?:'([^'\\]*)'|"([^"\\]*)")`)
+
+// gwtStrongNameRegex matches any strong name.
+var gwtStrongNameRegex = regexp.MustCompile(`['"]([0-9A-F]{32})['"]`)
+
+// gwtStrongNameValueRegex matches a string that is a strong name.
+var gwtStrongNameValueRegex = regexp.MustCompile(`^[0-9A-F]{32}$`)
+
+// DiscoverGWTValues discovers the current GWT values from the Cronometer web app.
+func DiscoverGWTValues(ctx context.Context) (GWTValues, error) {
+	return NewClient(nil).DiscoverGWTValues(ctx)
+}
+
+// DiscoverGWTValues discovers the current GWT values by loading the GWT bootstrap of the web app from the module base
+// of the client. The permutation is selected from the nocache.js bootstrap and the policy strong name of the service is
+// extracted from the cache.js of that permutation. The client is not updated with the values.
+func (c *Client) DiscoverGWTValues(ctx context.Context) (GWTValues, error) {
+	moduleBase := strings.TrimSuffix(c.state().gwtModuleBase, "/") + "/"
+	moduleName := path.Base(strings.TrimSuffix(moduleBase, "/"))
+
+	nocache, err := c.fetchGWTScript(ctx, moduleBase+moduleName+".nocache.js")
+	if err != nil {
+		return GWTValues{}, fmt.Errorf("failed to retrieve GWT bootstrap: %w", err)
+	}
+
+	permutation, err := selectGWTPermutation(nocache)
+	if err != nil {
+		return GWTValues{}, err
+	}
+
+	cache, err := c.fetchGWTScript(ctx, moduleBase+permutation+".cache.js")
+	if err != nil {
+		return GWTValues{}, fmt.Errorf("failed to retrieve GWT permutation %s: %w", permutation, err)
+	}
+
+	serviceURL, err := url.Parse(c.GWTBaseURL)
+	if err != nil {
+		return GWTValues{}, fmt.Errorf("failed to parse GWT base url: %w", err)
+	}
+
+	header, err := findGWTPolicy(cache, path.Base(serviceURL.Path))
+	if err != nil {
+		return GWTValues{}, err
+	}
+
+	return GWTValues{Permutation: permutation, Header: header}, nil
+}
+
+// fetchGWTScript retrieves the script at u.
+func (c *Client) fetchGWTScript(ctx context.Context, u string) (string, error) {
+	ctx = withOperation(ctx, OpGWTDiscovery)
+	resp, err := c.do(ctx, func(int) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed issuing HTTP request: %w", err)
+	}
+	defer closeAndExhaustReader(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Op: "gwt script " + path.Base(u), StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// selectGWTPermutation selects the permutation strong name from the nocache.js bootstrap. The permutation mapped to the
+// most preferred of DiscoveryUserAgents is selected. If the bootstrap has no mappings but a single strong name, that
+// name is selected.
+func selectGWTPermutation(nocache string) (string, error) {
+	vars := gwtStringVars(nocache)
+
+	permutations := make(map[string]string)
+	for _, match := range gwtPermutationRegex.FindAllStringSubmatch(nocache, -1) {
+		permutation, ok := resolveGWTString(vars, match[2])
+		if !ok || !gwtStrongNameValueRegex.MatchString(permutation) {
+			continue
+		}
+
+		for _, value := range strings.Split(match[1], ",") {
+			if value, ok = resolveGWTString(vars, strings.TrimSpace(value)); !ok {
+				continue
+			}
+			if _, ok = permutations[value]; !ok {
+				permutations[value] = permutation
+			}
+		}
+	}
+
+	for _, agent := range DiscoveryUserAgents {
+		if permutation, ok := permutations[agent]; ok {
+			return permutation, nil
+		}
+	}
+
+	names := uniqueStrongNames(nocache)
+	if len(names) == 1 {
+		return names[0], nil
+	}
+
+	return "", fmt.Errorf("%w: failed to select a permutation from the GWT bootstrap, found %d candidates",
+		ErrUnexpectedResponse, len(names))
+}
+
+// gwtStringVars returns the string literals assigned to variables in the script by their variable name. Only the
+// first assignment of a variable is kept.
+func gwtStringVars(script string) map[string]string {
+	vars := make(map[string]string)
+	for _, match := range gwtStringVarRegex.FindAllStringSubmatch(script, -1) {
+		if _, ok := vars[match[1]]; !ok {
+			vars[match[1]] = match[2] + match[3]
+		}
+	}
+	return vars
+}
+
+// resolveGWTString returns the string of token, which is either a quoted string literal or the name of a variable in
+// vars. False is returned if token is a variable that was not assigned a string.
+func resolveGWTString(vars map[string]string, token string) (string, bool) {
+	if len(token) >= 2 && (token[0] == '\'' || token[0] == '"') {
+		return token[1 : len(token)-1], true
+	}
+	s, ok := vars[token]
+	return s, ok
+}
+
+// findGWTPolicy finds the policy strong name passed along with the relative path of the service in the cache.js of a
+// permutation. The script may be embedded in a string, so escaped quotes are permitted.
+func findGWTPolicy(cache string, relativePath string) (string, error) {
+	policyRegex, err := regexp.Compile(`\\?['"]` + regexp.QuoteMeta(relativePath) + `\\?['"]\s*,\s*\\?['"]([0-9A-F]{32})\\?['"]`)
+	if err != nil {
+		return "", err
+	}
+
+	match := policyRegex.FindStringSubmatch(cache)
+	if len(match) != 2 {
+		return "", fmt.Errorf("%w: failed to find the policy strong name of service %s in the GWT permutation",
+			ErrUnexpectedResponse, relativePath)
+	}
+
+	return match[1], nil
+}
+
+// uniqueStrongNames returns the distinct strong names in s in order of appearance.
+func uniqueStrongNames(s string) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, match := range gwtStrongNameRegex.FindAllStringSubmatch(s, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// ensureGWTValues discovers and applies the GWT values if discovery is enabled and they have not been discovered yet.
+func (c *Client) ensureGWTValues(ctx context.Context) error {
+	if !c.DiscoverGWT {
+		return nil
+	}
+
+	c.discoverMu.Lock()
+	defer c.discoverMu.Unlock()
+
+	if c.gwtDiscovered {
+		return nil
+	}
+
+	values, err := c.DiscoverGWTValues(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to discover GWT values: %w", err)
+	}
+
+	c.mu.Lock()
+	c.GWTPermutation = values.Permutation
+	c.GWTHeader = values.Header
+	c.mu.Unlock()
+
+	c.gwtDiscovered = true
+	c.logger().InfoContext(ctx, "gwt values discovered",
+		slog.String("permutation", values.Permutation), slog.String("header", values.Header))
+
+	return nil
+}
+
+// checkGWTValues marks the discovered GWT values as stale if err indicates they no longer match the web app so they
+// are discovered again on the next GWT request. The err provided is returned.
+func (c *Client) checkGWTValues(err error) error {
+	if c.DiscoverGWT && errors.Is(err, ErrGWTVersionMismatch) {
+		c.discoverMu.Lock()
+		c.gwtDiscovered = false
+		c.discoverMu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+const (
+	testPermutation = "4E1B5F0C7A9D3E2B8C6A1F0D9E7B5C3A"
+	testHeader      = "C3A1E5B7D9F0A2C4E6B8D0F1A3C5E7B9"
+)
+
+func TestClient_DiscoverGWTValues(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetGWTPermutation(testPermutation)
+	srv.SetGWTHeader(testHeader)
+
+	values, err := srv.NewClient().DiscoverGWTValues(context.Background())
+	if err != nil {
+		t.Fatalf("failed to discover GWT values: %s", err)
+	}
+
+	if values.Permutation != testPermutation {
+		t.Fatalf("expected permutation %s but found %s", testPermutation, values.Permutation)
+	}
+	if values.Header != testHeader {
+		t.Fatalf("expected header %s but found %s", testHeader, values.Header)
+	}
+}
+
+func TestClient_DiscoverGWT(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetGWTPermutation(testPermutation)
+	srv.SetGWTHeader(testHeader)
+
+	err := srv.NewClient().Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrGWTVersionMismatch) {
+		t.Fatalf("expected ErrGWTVersionMismatch with stale values but received %v", err)
+	}
+
+	opts := srv.ClientOptions()
+	opts.DiscoverGWT = true
+	client := gocronometer.NewClient(opts)
+
+	if err = client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login with discovered values: %s", err)
+	}
+
+	if client.GWTPermutation != testPermutation || client.GWTHeader != testHeader {
+		t.Fatalf("client was not updated with the discovered values")
+	}
+
+	// A new release of the web app is detected and the values are discovered again.
+	srv.SetGWTHeader(gocronometer.GWTHeader)
+	if _, err = client.GenerateAuthToken(context.Background()); !errors.Is(err, gocronometer.ErrGWTVersionMismatch) {
+		t.Fatalf("expected ErrGWTVersionMismatch after release but received %v", err)
+	}
+	if _, err = client.GenerateAuthToken(context.Background()); err != nil {
+		t.Fatalf("failed to generate token after values were discovered again: %s", err)
+	}
+}
@@ -0,0 +1,226 @@
+package gocronometer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The following are the sentinel errors returned by the client. They are wrapped with additional context and should be
+// compared with errors.Is.
+var (
+	// ErrInvalidCredentials indicates the login was rejected by Cronometer.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrTwoFactorRequired indicates the login requires a second factor and no code could be provided.
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+
+	// ErrInvalidTwoFactorCode indicates the code of the second factor was rejected.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+	// ErrMissingAntiCSRF indicates the anticsrf value could not be found in the login page.
+	ErrMissingAntiCSRF = errors.New("anticsrf value not found in login page")
+
+	// ErrBotChallenge indicates a bot challenge, such as a Cloudflare challenge, was served in place of the login page.
+	ErrBotChallenge = errors.New("bot challenge served in place of login page")
+
+	// ErrCaptchaRequired indicates the login page requires a captcha to be solved.
+	ErrCaptchaRequired = errors.New("captcha required by login page")
+
+	// ErrMaintenance indicates Cronometer is down for maintenance.
+	ErrMaintenance = errors.New("cronometer is down for maintenance")
+
+	// ErrAccountLocked indicates the login page reports the account or client is locked out.
+	ErrAccountLocked = errors.New("account locked")
+
+	// ErrLoginFormChanged indicates the login page was served but its form no longer contains the anticsrf value,
+	// most likely because the page was redesigned.
+	ErrLoginFormChanged = errors.New("login form changed")
+
+	// ErrSessionExpired indicates the session is no longer valid and a new login is required.
+	ErrSessionExpired = errors.New("session expired")
+
+	// ErrGWTVersionMismatch indicates the GWT magic values in use no longer match the deployed web app.
+	ErrGWTVersionMismatch = errors.New("gwt version mismatch")
+
+	// ErrUnexpectedResponse indicates a response was received that the client does not know how to handle.
+	ErrUnexpectedResponse = errors.New("unexpected response")
+)
+
+// LoginError is returned when the login API rejects a login. It matches ErrInvalidCredentials.
+type LoginError struct {
+	// Message is the error message provided in the login response.
+	Message string
+}
+
+func (e *LoginError) Error() string {
+	return fmt.Sprintf("login rejected: %s", e.Message)
+}
+
+func (e *LoginError) Unwrap() error {
+	return ErrInvalidCredentials
+}
+
+// TwoFactorError is returned when the code of the second factor is rejected. It matches ErrInvalidTwoFactorCode.
+type TwoFactorError struct {
+	// Message is the error message provided in the response.
+	Message string
+}
+
+func (e *TwoFactorError) Error() string {
+	return fmt.Sprintf("two-factor code rejected: %s", e.Message)
+}
+
+func (e *TwoFactorError) Unwrap() error {
+	return ErrInvalidTwoFactorCode
+}
+
+// LoginPageError is returned when the login page, or the page of the second factor, does not provide the anticsrf
+// value. It matches ErrMissingAntiCSRF along with the Reason the page was classified as, one of ErrBotChallenge,
+// ErrCaptchaRequired, ErrMaintenance, ErrAccountLocked or ErrLoginFormChanged.
+type LoginPageError struct {
+	// Op is the operation that received the page such as "login page".
+	Op string
+
+	// Reason is the sentinel error the page was classified as.
+	Reason error
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Title is the title of the page if it has one.
+	Title string
+
+	// Snapshot is the start of the body of the page with secrets, email addresses and IP addresses redacted, for
+	// inclusion in bug reports.
+	Snapshot string
+}
+
+func (e *LoginPageError) Error() string {
+	return fmt.Sprintf("%s: %s (status %d, title %q)", e.Op, e.Reason, e.StatusCode, e.Title)
+}
+
+func (e *LoginPageError) Unwrap() []error {
+	return []error{e.Reason, ErrMissingAntiCSRF}
+}
+
+// StatusError is returned when a request receives a non 200 response. A 401 or 403 response matches
+// ErrSessionExpired.
+type StatusError struct {
+	// Op is the operation that received the response such as "login" or "servings export".
+	Op string
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Body is the body of the response if it was read.
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("received non 200 response of %d for %s", e.StatusCode, e.Op)
+	}
+	return fmt.Sprintf("received non 200 response of %d for %s: body [%s]", e.StatusCode, e.Op, e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrSessionExpired &&
+		(e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
+
+// GWTError is returned when a GWT call responds with an exception. Exceptions indicating the session is no longer
+// logged in match ErrSessionExpired and those indicating the GWT values are out of date match ErrGWTVersionMismatch.
+type GWTError struct {
+	// Method is the GWT method that was called.
+	Method string
+
+	// Exception is the Java class of the exception thrown by the GWT service if it could be determined.
+	Exception string
+
+	// Message is the message of the exception if it has one.
+	Message string
+
+	// Body is the raw body of the response.
+	Body string
+}
+
+func (e *GWTError) Error() string {
+	switch {
+	case e.Exception == "":
+		return fmt.Sprintf("gwt %s failed: %s", e.Method, e.Body)
+	case e.Message == "":
+		return fmt.Sprintf("gwt %s failed with %s", e.Method, e.Exception)
+	default:
+		return fmt.Sprintf("gwt %s failed with %s: %s", e.Method, e.Exception, e.Message)
+	}
+}
+
+func (e *GWTError) Is(target error) bool {
+	switch target {
+	case ErrSessionExpired:
+		return strings.Contains(e.Exception, "NotLoggedIn") || strings.Contains(e.Exception, "SessionExpired")
+	case ErrGWTVersionMismatch:
+		return strings.Contains(e.Exception, "IncompatibleRemoteServiceException") ||
+			strings.Contains(e.Exception, "SerializationException")
+	}
+	return false
+}
+
+// WindowError is the failure of a single window of a chunked export.
+type WindowError struct {
+	// Window is the range of days that failed.
+	Window DateWindow
+
+	// Err is the reason the window failed.
+	Err error
+}
+
+func (e WindowError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Window, e.Err)
+}
+
+func (e WindowError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkError is returned when some windows of a chunked export fail. The data of the windows that succeeded is
+// returned alongside it and the failed windows can be exported again on their own. It matches the errors of every
+// failed window.
+type ChunkError struct {
+	// Kind is the kind of the export.
+	Kind ExportKind
+
+	// Total is the number of windows the export was split into.
+	Total int
+
+	// Failed are the windows that failed in date order.
+	Failed []WindowError
+}
+
+func (e *ChunkError) Error() string {
+	failures := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		failures[i] = f.Error()
+	}
+	return fmt.Sprintf("%d of %d windows of the %s export failed: %s", len(e.Failed), e.Total, e.Kind,
+		strings.Join(failures, "; "))
+}
+
+func (e *ChunkError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// Windows returns the windows that failed.
+func (e *ChunkError) Windows() []DateWindow {
+	windows := make([]DateWindow, len(e.Failed))
+	for i, f := range e.Failed {
+		windows[i] = f.Window
+	}
+	return windows
+}
@@ -0,0 +1,141 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_Login_InvalidCredentialsError(t *testing.T) {
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, "BAD")
+	if !errors.Is(err, gocronometer.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials but received %v", err)
+	}
+
+	var loginErr *gocronometer.LoginError
+	if !errors.As(err, &loginErr) || loginErr.Message == "" {
+		t.Fatalf("expected a LoginError with a message but received %v", err)
+	}
+}
+
+func TestClient_Login_MissingAntiCSRFError(t *testing.T) {
+	srv := newTestServer(t)
+	srv.InjectFailure(gocronometertest.EndpointLoginPage, gocronometertest.FailMissingAntiCSRF, 1)
+
+	err := srv.NewClient().Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrMissingAntiCSRF) {
+		t.Fatalf("expected ErrMissingAntiCSRF but received %v", err)
+	}
+}
+
+func TestClient_Login_LoginPageError(t *testing.T) {
+	tests := []struct {
+		failure gocronometertest.Failure
+		reason  error
+		status  int
+	}{
+		{gocronometertest.FailBotChallenge, gocronometer.ErrBotChallenge, http.StatusForbidden},
+		{gocronometertest.FailCaptchaRequired, gocronometer.ErrCaptchaRequired, http.StatusOK},
+		{gocronometertest.FailMaintenance, gocronometer.ErrMaintenance, http.StatusServiceUnavailable},
+		{gocronometertest.FailAccountLocked, gocronometer.ErrAccountLocked, http.StatusOK},
+		{gocronometertest.FailMissingAntiCSRF, gocronometer.ErrLoginFormChanged, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.reason.Error(), func(t *testing.T) {
+			srv := newTestServer(t)
+			srv.InjectFailure(gocronometertest.EndpointLoginPage, test.failure, 1)
+
+			err := srv.NewClient().Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+			if !errors.Is(err, test.reason) || !errors.Is(err, gocronometer.ErrMissingAntiCSRF) {
+				t.Fatalf("expected %v matching ErrMissingAntiCSRF but received %v", test.reason, err)
+			}
+
+			var pageErr *gocronometer.LoginPageError
+			if !errors.As(err, &pageErr) {
+				t.Fatalf("expected a LoginPageError but received %v", err)
+			}
+			if pageErr.StatusCode != test.status || pageErr.Title == "" || pageErr.Snapshot == "" {
+				t.Fatalf("expected status %d with a title and snapshot but received %+v", test.status, pageErr)
+			}
+			if strings.Contains(pageErr.Snapshot, "203.0.113.7") {
+				t.Fatalf("expected the IP address to be redacted from the snapshot: %s", pageErr.Snapshot)
+			}
+		})
+	}
+}
+
+func TestClient_Login_UnclassifiedStatusError(t *testing.T) {
+	srv := newTestServer(t)
+	srv.InjectFailure(gocronometertest.EndpointLoginPage, gocronometertest.FailInternalError, 1)
+
+	err := srv.NewClient().Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a StatusError of 500 but received %v", err)
+	}
+}
+
+func TestClient_GenerateAuthToken_SessionExpiredError(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.ExpireSessions()
+
+	_, err := client.GenerateAuthToken(context.Background())
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired but received %v", err)
+	}
+
+	var gwtErr *gocronometer.GWTError
+	if !errors.As(err, &gwtErr) || gwtErr.Method != "generateAuthorizationToken" {
+		t.Fatalf("expected a GWTError for generateAuthorizationToken but received %v", err)
+	}
+}
+
+func TestClient_GenerateAuthToken_VersionMismatchError(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.InjectFailure(gocronometertest.EndpointGWTGenerateToken, gocronometertest.FailVersionMismatch, 1)
+
+	_, err := client.GenerateAuthToken(context.Background())
+	if !errors.Is(err, gocronometer.ErrGWTVersionMismatch) {
+		t.Fatalf("expected ErrGWTVersionMismatch but received %v", err)
+	}
+	if errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("version mismatch should not match ErrSessionExpired")
+	}
+}
+
+func TestClient_ExportServings_StatusError(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 1)
+
+	_, err := client.ExportServings(context.Background(), time.Now(), time.Now())
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected a StatusError but received %v", err)
+	}
+	if statusErr.StatusCode != http.StatusInternalServerError || statusErr.Body == "" {
+		t.Fatalf("expected a 500 status with a body but received %d [%s]", statusErr.StatusCode, statusErr.Body)
+	}
+}
+
+func TestClient_ExportNotes_RedirectedToLoginError(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	// The export is tried again with a new token before the session is considered expired.
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailExpiredSession, 2)
+
+	_, err := client.ExportNotes(context.Background(), time.Now(), time.Now())
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired but received %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package gocronometer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ExportKind is the kind of data requested from the export API. It is sent as the generate parameter of the export
+// request. Kinds not listed below can be exported by converting their generate value, for example
+// ExportKind("newKind").
+type ExportKind string
+
+// The following are the export kinds known to the library.
+const (
+	ExportKindDailyNutrition ExportKind = "dailySummary"
+	ExportKindServings       ExportKind = "servings"
+	ExportKindExercises      ExportKind = "exercises"
+	ExportKindBiometrics     ExportKind = "biometrics"
+	ExportKindNotes          ExportKind = "notes"
+)
+
+// Export exports the data of the kind provided within the date range. Only the YYYY-mm-dd is utilized of startDate and
+// endDate. The export is the raw string data.
+func (c *Client) Export(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (string, error) {
+	body, err := c.ExportStream(ctx, kind, startDate, endDate)
+	if err != nil {
+		return "", err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read body of %s export response: %w", kind, err)
+	}
+
+	return string(data), nil
+}
+
+// ExportStream exports the data of the kind provided within the date range and returns the body of the response once
+// its status has been validated. Only the YYYY-mm-dd is utilized of startDate and endDate. The raw CSV data is read
+// from the body as it is received, which allows large exports to be parsed or written out without holding them in
+// memory. The body must be closed by the caller.
+func (c *Client) ExportStream(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (io.ReadCloser, error) {
+	if kind == "" {
+		return nil, fmt.Errorf("export kind must not be empty")
+	}
+
+	attrs := []any{
+		slog.String("kind", string(kind)),
+		slog.String("start", startDate.Format("2006-01-02")),
+		slog.String("end", endDate.Format("2006-01-02")),
+	}
+	c.logger().DebugContext(ctx, "export started", attrs...)
+	start := time.Now()
+
+	var resp *http.Response
+	err := c.withSessionRenewal(ctx, func() (err error) {
+		resp, err = c.exportStream(ctx, kind, startDate, endDate)
+
+		// A rejected export may only mean the cached token is no longer accepted, so a new token is tried before the
+		// session is considered expired.
+		if errors.Is(err, ErrSessionExpired) && c.dropExportToken() {
+			c.logger().DebugContext(ctx, "export token rejected, retrying with a new token", c.errAttr(err))
+			resp, err = c.exportStream(ctx, kind, startDate, endDate)
+		}
+		return err
+	})
+
+	if err != nil {
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+		c.logger().ErrorContext(ctx, "export failed", append(attrs, c.errAttr(err))...)
+		return nil, err
+	}
+
+	body := &exportBody{
+		ReadCloser: resp.Body,
+		client:     c,
+		ctx:        ctx,
+		attrs:      attrs,
+		start:      start,
+		progress: Progress{
+			Phase:      PhaseDownloading,
+			Kind:       kind,
+			Window:     DateWindow{Start: startDate, End: endDate},
+			BytesTotal: resp.ContentLength,
+		},
+		reporting: hasProgress(ctx),
+	}
+	if body.reporting {
+		reportProgress(ctx, body.progress)
+	}
+
+	return body, nil
+}
+
+// exportStream is ExportStream without session renewal. The body of a failed response is read and closed.
+func (c *Client) exportStream(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (*http.Response, error) {
+	// Executing the request.
+	resp, err := c.doExportRequest(ctx, kind, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed while executing http request for %s export: %w", kind, err)
+	}
+
+	// Handling the response. The body of a failure is only read to be included in the error.
+	op := string(kind) + " export"
+	if err = c.checkExportResponse(op, resp, nil); err != nil {
+		//noinspection GoUnhandledErrorResult
+		defer closeAndExhaustReader(resp.Body)
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			body, _ := io.ReadAll(resp.Body)
+			statusErr.Body = c.Redact(string(body))
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// exportBody is the body of a streamed export. It counts the bytes read, reports them as progress and logs the outcome
+// of the export when closed.
+type exportBody struct {
+	io.ReadCloser
+
+	client *Client
+	ctx    context.Context
+	attrs  []any
+	start  time.Time
+
+	// progress is reported after every read if reporting is true.
+	progress  Progress
+	reporting bool
+
+	bytes   int
+	readErr error
+	once    sync.Once
+}
+
+// Read reads from the body of the export.
+func (b *exportBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += n
+	if err != nil && err != io.EOF {
+		b.readErr = err
+	}
+	if b.reporting && n > 0 {
+		b.progress.BytesReceived = int64(b.bytes)
+		reportProgress(b.ctx, b.progress)
+	}
+	return n, err
+}
+
+// Close closes the body of the export. The outcome of the export is logged on the first call.
+func (b *exportBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	b.once.Do(func() {
+		attrs := append(b.attrs, slog.Duration("duration", time.Since(b.start)))
+		if b.readErr != nil {
+			b.client.logger().ErrorContext(b.ctx, "export failed", append(attrs, b.client.errAttr(b.readErr))...)
+			return
+		}
+		b.client.logger().InfoContext(b.ctx, "export finished", append(attrs, slog.Int("bytes", b.bytes))...)
+	})
+
+	return err
+}
+
+// checkExportResponse returns an error if resp is not a successful export response. A response that was redirected to
+// the login page indicates the session has expired. The body is included in the error, with secrets redacted, if it has
+// been read.
+func (c *Client) checkExportResponse(op string, resp *http.Response, body []byte) error {
+	if resp.StatusCode != 200 {
+		return &StatusError{Op: op, StatusCode: resp.StatusCode, Body: c.Redact(string(body))}
+	}
+
+	if loginURL, err := url.Parse(c.HTMLLoginURL); err == nil && resp.Request != nil &&
+		resp.Request.URL.Host == loginURL.Host && resp.Request.URL.Path == loginURL.Path {
+		return fmt.Errorf("%w: %s was redirected to the login page", ErrSessionExpired, op)
+	}
+
+	return nil
+}
+
+// doExportRequest executes the export request of the kind provided for the date range with the cached export token.
+// The token is looked up for every attempt so an attempt after a rejected token uses a new one.
+func (c *Client) doExportRequest(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (*http.Response, error) {
+	exportCtx := withOperation(ctx, OpExportPrefix+string(kind))
+	return c.do(exportCtx, func(int) (*http.Request, error) {
+		// Obtaining the required token.
+		token, err := c.exportToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token to make request: %w", err)
+		}
+
+		// Building the request.
+		req, err := c.NewExportRequest(exportCtx, "GET", c.APIExportURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed while building http request: %w", err)
+		}
+
+		q := req.URL.Query()
+		q.Add("nonce", token)
+		q.Add("generate", string(kind))
+		q.Add("start", startDate.Format("2006-01-02"))
+		q.Add("end", endDate.Format("2006-01-02"))
+		req.URL.RawQuery = q.Encode()
+
+		return req, nil
+	})
+}
+
+// NewExportRequest creates a new http request for exports.
+func (c *Client) NewExportRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("sec-fetch-dest", "document")
+	req.Header.Add("sec-fetch-mode", "navigate")
+	req.Header.Add("sec-fetch-site", "same-origin")
+
+	return req, nil
+}
@@ -0,0 +1,148 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_Export(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	wrappers := map[gocronometer.ExportKind]func(context.Context, time.Time, time.Time) (string, error){
+		gocronometer.ExportKindDailyNutrition: client.ExportDailyNutrition,
+		gocronometer.ExportKindServings:       client.ExportServings,
+		gocronometer.ExportKindExercises:      client.ExportExercises,
+		gocronometer.ExportKindBiometrics:     client.ExportBiometrics,
+		gocronometer.ExportKindNotes:          client.ExportNotes,
+	}
+
+	for kind, wrapper := range wrappers {
+		data, err := client.Export(context.Background(), kind, start, end)
+		if err != nil {
+			t.Fatalf("failed to export %s: %s", kind, err)
+		}
+
+		expected, err := wrapper(context.Background(), start, end)
+		if err != nil {
+			t.Fatalf("failed to export %s: %s", kind, err)
+		}
+
+		if data != expected || !strings.Contains(data, "\n") {
+			t.Fatalf("expected the %s export to match its wrapper but found [%s] and [%s]", kind, data, expected)
+		}
+	}
+}
+
+func TestClient_Export_ArbitraryKind(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	_, err := client.Export(context.Background(), gocronometer.ExportKind("recipes"), time.Now(), time.Now())
+
+	// The fake server does not know the kind, which shows the generate value was passed through.
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected a StatusError but received %v", err)
+	}
+	if statusErr.StatusCode != http.StatusBadRequest || !strings.Contains(statusErr.Body, "unknown export") {
+		t.Fatalf("expected a 400 status with the body but received %d [%s]", statusErr.StatusCode, statusErr.Body)
+	}
+	if statusErr.Op != "recipes export" {
+		t.Fatalf("expected the op to name the kind but found %s", statusErr.Op)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 1 {
+		t.Fatalf("expected 1 export request but found %d", n)
+	}
+}
+
+func TestClient_Export_EmptyKind(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	if _, err := client.Export(context.Background(), "", time.Now(), time.Now()); err == nil {
+		t.Fatalf("expected an error for an empty kind")
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 0 {
+		t.Fatalf("expected no export requests but found %d", n)
+	}
+}
+
+func TestClient_Export_StatusErrorBody(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	// Every kind includes the body of a failed response in its error.
+	for _, kind := range []gocronometer.ExportKind{gocronometer.ExportKindBiometrics, gocronometer.ExportKindNotes} {
+		srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 1)
+
+		_, err := client.Export(context.Background(), kind, time.Now(), time.Now())
+
+		var statusErr *gocronometer.StatusError
+		if !errors.As(err, &statusErr) || statusErr.Body == "" {
+			t.Fatalf("expected a StatusError with a body for %s but received %v", kind, err)
+		}
+	}
+}
+
+func TestClient_ExportStream(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	body, err := client.ExportStream(context.Background(), gocronometer.ExportKindServings, start, end)
+	if err != nil {
+		t.Fatalf("failed to stream servings: %s", err)
+	}
+	defer body.Close()
+
+	servings, err := gocronometer.ParseServingsExport(body, time.UTC)
+	if err != nil {
+		t.Fatalf("failed to parse streamed servings: %s", err)
+	}
+
+	if len(servings) != 2 || servings[0].FoodName != "Oatmeal" {
+		t.Fatalf("expected the 2 seeded servings but found %+v", servings)
+	}
+}
+
+func TestClient_ExportStream_StatusError(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 1)
+
+	body, err := client.ExportStream(context.Background(), gocronometer.ExportKindNotes, time.Now(), time.Now())
+	if body != nil {
+		t.Fatalf("expected no body for a failed export")
+	}
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError || statusErr.Body == "" {
+		t.Fatalf("expected a 500 StatusError with a body but received %v", err)
+	}
+}
+
+func TestClient_ExportStream_SessionRenewal(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+	srv.ExpireSessions()
+
+	body, err := client.ExportStream(context.Background(), gocronometer.ExportKindBiometrics,
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to stream after the session expired: %s", err)
+	}
+	defer body.Close()
+
+	records, err := gocronometer.ParseBiometricRecordsExport(body, time.UTC)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("expected 1 biometric record but found %d: %v", len(records), err)
+	}
+}
@@ -0,0 +1,36 @@
+package gocronometer
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Authenticator logs in to and out of Cronometer.
+type Authenticator interface {
+	Login(ctx context.Context, username string, password string) error
+	Logout(ctx context.Context) error
+}
+
+// RawExporter exports the raw CSV data of an export kind.
+type RawExporter interface {
+	Export(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (string, error)
+	ExportStream(ctx context.Context, kind ExportKind, startDate time.Time, endDate time.Time) (io.ReadCloser, error)
+}
+
+// ParsedExporter exports the records of the kinds that have a parser with recorded times in the location provided.
+type ParsedExporter interface {
+	ExportServingsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (ServingRecords, error)
+	ExportExercisesParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (ExerciseRecords, error)
+	ExportBiometricRecordsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (BiometricRecords, error)
+}
+
+// Exporter is the combination of the interfaces the client satisfies. Code that depends on it rather than on Client
+// can be tested against gocronometertest.MemoryClient.
+type Exporter interface {
+	Authenticator
+	RawExporter
+	ParsedExporter
+}
+
+var _ Exporter = (*Client)(nil)
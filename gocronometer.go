@@ -1,59 +1,226 @@
 package gocronometer
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/html"
 )
 
 const (
+	// DefaultBaseURL is the scheme and host all of the Cronometer endpoints are served from.
+	DefaultBaseURL = "https://cronometer.com"
+
 	// HTMLLoginURL is the full URL to the Cronometer login page.
-	HTMLLoginURL = "https://cronometer.com/login/"
+	HTMLLoginURL = DefaultBaseURL + htmlLoginPath
 
 	// APILoginURL is the full URL for login requests.
-	APILoginURL = "https://cronometer.com/login"
+	APILoginURL = DefaultBaseURL + apiLoginPath
 
 	// GWTBaseURL is the full URL for accessing the GWT API.
-	GWTBaseURL = "https://cronometer.com/cronometer/app"
+	GWTBaseURL = DefaultBaseURL + gwtBasePath
 
 	// APIExportURL is the full URL for requesting data exports.
-	APIExportURL = "https://cronometer.com/export"
+	APIExportURL = DefaultBaseURL + apiExportPath
+)
+
+// The following are the paths of each endpoint relative to the base URL.
+const (
+	htmlLoginPath = "/login/"
+	apiLoginPath  = "/login"
+	gwtBasePath   = "/cronometer/app"
+	gwtModulePath = "/cronometer/"
+	apiExportPath = "/export"
 )
 
+// GWTTokenRegex matches the token in the response of a GWT token generation.
+//
+// Deprecated: The client decodes GWT responses with DecodeGWTResponse.
 var GWTTokenRegex = regexp.MustCompile("\"(?P<token>.*)\"")
 
+// GWTAuthRegex matches the user ID in the response of a GWT authentication.
+//
+// Deprecated: The client decodes GWT responses with DecodeGWTResponse.
 const GWTAuthRegex = `OK\[(?P<userid>\d*),.*`
 
+// GWTAuthenticationRegexp is the compiled GWTAuthRegex.
+//
+// Deprecated: The client decodes GWT responses with DecodeGWTResponse.
 var GWTAuthenticationRegexp = regexp.MustCompile(GWTAuthRegex)
 
 // Client represents a client to the Cronometer API. The zero value is not a valid configuration. A new client should
 // be generated with the NewClient function.
+//
+// A Client is safe for concurrent use by multiple goroutines. The exported fields configure the client and must not be
+// modified once it is in use. Nonce, UserID and the GWT values are maintained by the client and should be read with
+// SessionNonce, SessionUserID or Session while it is in use.
 type Client struct {
 	HTTPClient *http.Client
-	Nonce      string
-	UserID     string
+
+	// Nonce is the sesnonce of the session. It is maintained by the client and reading it races with a renewal.
+	//
+	// Deprecated: Use SessionNonce.
+	Nonce string
+
+	// UserID is the user ID of the session. It is maintained by the client and reading it races with a renewal.
+	//
+	// Deprecated: Use SessionUserID.
+	UserID string
+
+	HTMLLoginURL string
+	APILoginURL  string
+	GWTBaseURL   string
+	APIExportURL string
 
 	GWTContentType string
 	GWTModuleBase  string
 	GWTPermutation string
 	GWTHeader      string
+
+	// GWTLocation is the location whose offset is provided when authenticating with the GWT API. If nil,
+	// DefaultGWTTimezoneOffset is provided.
+	GWTLocation *time.Location
+
+	// DiscoverGWT causes the GWT permutation and header to be discovered from the web app before the first GWT request
+	// and again after a GWT version mismatch.
+	DiscoverGWT bool
+
+	// RateLimiter limits the rate of every request made through the transport of HTTPClient. If nil requests are not
+	// limited.
+	RateLimiter *RateLimiter
+
+	// Logger receives structured events for each step of the client. Credentials and nonces are never logged. If nil
+	// nothing is logged.
+	Logger *slog.Logger
+
+	// Hooks are called around every request made through the transport of HTTPClient.
+	Hooks []Hooks
+
+	// RetryPolicy controls how transiently failing requests are retried. If nil requests are not retried.
+	RetryPolicy *RetryPolicy
+
+	// Credentials provides the credentials used to renew the session when it expires. If nil, expired sessions are
+	// returned as errors matching ErrSessionExpired.
+	Credentials CredentialsProvider
+
+	// SessionStore, when set, receives the session after every successful login and is checked for a session saved by
+	// another process before an expired session is renewed.
+	SessionStore SessionStore
+
+	// TokenLifetime is the lifetime requested for export tokens. Tokens are cached and reused by exports until shortly
+	// before they expire. If zero, DefaultTokenLifetime is requested.
+	TokenLifetime time.Duration
+
+	// AuthScope is the scope requested for export tokens.
+	AuthScope AuthScope
+
+	// ExportConcurrency is the number of exports ExportAll fetches at once. If zero, DefaultExportConcurrency is
+	// used.
+	ExportConcurrency int
+
+	// TwoFactor provides the code of the second factor when a login requires one. If nil, such logins fail with an
+	// error matching ErrTwoFactorRequired.
+	TwoFactor TwoFactorFunc
+
+	// renewMu serializes session renewals and sessionGen is incremented on every successful login.
+	renewMu    sync.Mutex
+	sessionGen atomic.Uint64
+
+	// secrets are the secret values seen by the client that are removed by Redact.
+	secrets redactor
+
+	// mu guards Nonce, UserID and the GWT values once the client is in use.
+	mu sync.RWMutex
+
+	// discoverMu guards gwtDiscovered which is set once the GWT values have been discovered.
+	discoverMu    sync.Mutex
+	gwtDiscovered bool
+
+	// tokenMu guards token, the cached export token.
+	tokenMu sync.Mutex
+	token   exportToken
 }
 
 // ClientOptions represents the options that can be provided to the client. Zero values revert to the library defaults.
 type ClientOptions struct {
+	// BaseURL is the scheme and host, with an optional path prefix, that every endpoint URL is built from. This allows
+	// the client to be pointed at a local stand-in or proxy. The endpoint specific URLs below take precedence over it.
+	BaseURL string
+
+	HTMLLoginURL string
+	APILoginURL  string
+	GWTBaseURL   string
+	APIExportURL string
+
 	GWTContentType string
 	GWTModuleBase  string
 	GWTPermutation string
 	GWTHeader      string
+
+	// GWTLocation is the location whose offset is provided when authenticating with the GWT API.
+	GWTLocation *time.Location
+
+	// DiscoverGWT enables discovery of the GWT permutation and header from the web app on first use. The discovered
+	// values are cached and take precedence over GWTPermutation and GWTHeader.
+	DiscoverGWT bool
+
+	// RateLimiter replaces the default rate limiter of the client. Providing the same limiter to multiple clients
+	// limits them together.
+	RateLimiter *RateLimiter
+
+	// DisableRateLimit removes the rate limiter of the client.
+	DisableRateLimit bool
+
+	// Transport executes the requests of the client, such as a transport with a proxy or TLS configuration. It is
+	// wrapped by the client so requests still pass the rate limiter and hooks. If nil http.DefaultTransport is used.
+	// HTTPClient.Transport must not be replaced instead as that bypasses the rate limiter and hooks.
+	Transport http.RoundTripper
+
+	// Logger receives structured events for each step of the client such as logins, GWT calls and exports.
+	Logger *slog.Logger
+
+	// Hooks are called around every request the client makes.
+	Hooks []Hooks
+
+	// DebugWriter, when set, receives every request and response with secrets redacted.
+	DebugWriter io.Writer
+
+	// RetryPolicy enables retries of requests that fail transiently. DefaultRetryPolicy provides sensible values.
+	RetryPolicy *RetryPolicy
+
+	// Credentials enables automatic session renewal. When a request fails because the session expired the client logs
+	// in again with these credentials and replays the request once.
+	Credentials CredentialsProvider
+
+	// SessionStore enables sharing of the session. The session is saved to it after every login and a session saved by
+	// another process is tried before logging in again to renew an expired session.
+	SessionStore SessionStore
+
+	// TokenLifetime is the lifetime requested for export tokens.
+	TokenLifetime time.Duration
+
+	// AuthScope is the scope requested for export tokens. The zero value requests AuthScopeExport.
+	AuthScope AuthScope
+
+	// ExportConcurrency is the number of exports ExportAll fetches at once.
+	ExportConcurrency int
+
+	// TwoFactor provides the code of the second factor for accounts with two-factor authentication enabled. TOTP
+	// provides one for a base32 secret.
+	TwoFactor TwoFactorFunc
 }
 
 // updateOpts updates the client with the opts provided
@@ -63,6 +230,27 @@ func (c *Client) updateOpts(opts *ClientOptions) {
 		opts = &ClientOptions{}
 	}
 
+	if opts.BaseURL != "" {
+		base := strings.TrimSuffix(opts.BaseURL, "/")
+		c.HTMLLoginURL = base + htmlLoginPath
+		c.APILoginURL = base + apiLoginPath
+		c.GWTBaseURL = base + gwtBasePath
+		c.APIExportURL = base + apiExportPath
+		c.GWTModuleBase = base + gwtModulePath
+	}
+
+	if opts.HTMLLoginURL != "" {
+		c.HTMLLoginURL = opts.HTMLLoginURL
+	}
+	if opts.APILoginURL != "" {
+		c.APILoginURL = opts.APILoginURL
+	}
+	if opts.GWTBaseURL != "" {
+		c.GWTBaseURL = opts.GWTBaseURL
+	}
+	if opts.APIExportURL != "" {
+		c.APIExportURL = opts.APIExportURL
+	}
 	if opts.GWTContentType != "" {
 		c.GWTContentType = opts.GWTContentType
 	}
@@ -75,63 +263,148 @@ func (c *Client) updateOpts(opts *ClientOptions) {
 	if opts.GWTHeader != "" {
 		c.GWTHeader = opts.GWTHeader
 	}
+	if opts.GWTLocation != nil {
+		c.GWTLocation = opts.GWTLocation
+	}
+	if opts.DiscoverGWT {
+		c.DiscoverGWT = true
+	}
+	if opts.RateLimiter != nil {
+		c.RateLimiter = opts.RateLimiter
+	}
+	if opts.DisableRateLimit {
+		c.RateLimiter = nil
+	}
+	if opts.Logger != nil {
+		c.Logger = opts.Logger
+	}
+	if len(opts.Hooks) > 0 {
+		c.Hooks = append(c.Hooks, opts.Hooks...)
+	}
+	if opts.DebugWriter != nil {
+		c.Hooks = append(c.Hooks, c.DebugHooks(opts.DebugWriter))
+	}
+	if opts.RetryPolicy != nil {
+		c.RetryPolicy = opts.RetryPolicy
+	}
+	if opts.Credentials != nil {
+		c.Credentials = opts.Credentials
+	}
+	if opts.SessionStore != nil {
+		c.SessionStore = opts.SessionStore
+	}
+	if opts.TokenLifetime > 0 {
+		c.TokenLifetime = opts.TokenLifetime
+	}
+	if opts.AuthScope != 0 {
+		c.AuthScope = opts.AuthScope
+	}
+	if opts.ExportConcurrency > 0 {
+		c.ExportConcurrency = opts.ExportConcurrency
+	}
+	if opts.TwoFactor != nil {
+		c.TwoFactor = opts.TwoFactor
+	}
 }
 
-// NewClient generates a new client for the Cronometer API. If opts is nil the default values are utilized.
+// NewClient generates a new client for the Cronometer API. If opts is nil the default values are utilized. Requests are
+// rate limited to DefaultRateLimit per second unless another limiter is provided or limiting is disabled.
 func NewClient(opts *ClientOptions) *Client {
 	jar, _ := cookiejar.New(nil)
 	client := &Client{
 		HTTPClient: &http.Client{
 			Jar: jar,
 		},
-		GWTContentType: GWTContentType,
-		GWTModuleBase:  GWTModuleBase,
-		GWTPermutation: GWTPermutation,
+		RateLimiter:       NewRateLimiter(DefaultRateLimit, DefaultRateBurst),
+		HTMLLoginURL:      HTMLLoginURL,
+		APILoginURL:       APILoginURL,
+		GWTBaseURL:        GWTBaseURL,
+		APIExportURL:      APIExportURL,
+		GWTContentType:    GWTContentType,
+		GWTModuleBase:     GWTModuleBase,
+		GWTPermutation:    GWTPermutation,
+		GWTHeader:         GWTHeader,
+		TokenLifetime:     DefaultTokenLifetime,
+		AuthScope:         AuthScopeExport,
+		ExportConcurrency: DefaultExportConcurrency,
 	}
 
 	client.updateOpts(opts)
 
+	transport := &clientTransport{client: client}
+	if opts != nil {
+		transport.base = opts.Transport
+	}
+	client.HTTPClient.Transport = transport
+
 	return client
 }
 
 // NewGWTRequestWithContext creates a new http request with the proper headers for a GWT request.
 func (c *Client) NewGWTRequestWithContext(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
-	if err != nil {
-		return nil, err
-	}
+	return c.state().newGWTRequest(ctx, method, url, body)
+}
 
-	req.Header.Set("content-type", c.GWTContentType)
-	req.Header.Add("x-gwt-module-base", c.GWTModuleBase)
-	req.Header.Add("x-gwt-permutation", c.GWTPermutation)
+// doGWTRequest executes a GWT request of the operation op with the body provided. The headers are taken from the
+// snapshot the body was built from.
+func (c *Client) doGWTRequest(ctx context.Context, op string, st clientState, body string) (*http.Response, error) {
+	ctx = withOperation(ctx, op)
+	return c.do(ctx, func(int) (*http.Request, error) {
+		req, err := st.newGWTRequest(ctx, "POST", c.GWTBaseURL, strings.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed while building http request: %w", err)
+		}
+		return req, nil
+	})
+}
 
-	return req, nil
+// gwtTimezoneOffset returns the current offset of the GWTLocation of the client in minutes east of UTC.
+func (c *Client) gwtTimezoneOffset() int {
+	if c.GWTLocation == nil {
+		return DefaultGWTTimezoneOffset
+	}
+	_, offset := time.Now().In(c.GWTLocation).Zone()
+	return offset / 60
 }
 
 // ObtainAntiCSRF connects to the login page of Cronometer and parses out the anticsrf value from the HTML form.
 func (c *Client) ObtainAntiCSRF(ctx context.Context) (string, error) {
+	return c.obtainAntiCSRF(ctx, OpLoginPage, c.HTMLLoginURL)
+}
 
-	// Building and executing request to obtain the login page HTML.
-	req, err := http.NewRequestWithContext(ctx, "GET", HTMLLoginURL, nil)
+// obtainAntiCSRF loads the page at pageURL as the operation op and parses out the anticsrf value from the HTML form.
+func (c *Client) obtainAntiCSRF(ctx context.Context, op string, pageURL string) (string, error) {
+
+	// Building and executing request to obtain the page HTML.
+	ctx = withOperation(ctx, op)
+	resp, err := c.do(ctx, func(int) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request to retreive anticsrf value: %w", err)
+		}
+		return req, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to build request to retreive anticsrf value: %s", err)
+		return "", fmt.Errorf("failed issuing HTTP request: %w", err)
 	}
-	req = req.WithContext(ctx)
+	defer closeAndExhaustReader(resp.Body)
 
-	resp, err := c.HTTPClient.Do(req)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxLoginPageSize))
 	if err != nil {
-		return "", fmt.Errorf("failed issuing HTTP request: %s", err)
+		return "", fmt.Errorf("failed to read body of %s: %w", op, err)
 	}
-	defer closeAndExhaustReader(resp.Body)
 
 	// Handling the response.
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("recevied non OK error code %d", resp.StatusCode)
+		if reason := classifyLoginPage(resp, body); reason != nil {
+			return "", c.loginPageError(ctx, op, resp, body, reason)
+		}
+		return "", &StatusError{Op: strings.ReplaceAll(op, ".", " "), StatusCode: resp.StatusCode}
 	}
 
-	z, err := html.Parse(resp.Body)
+	z, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to parse HTML response: %s", err)
+		return "", fmt.Errorf("%w: failed to parse HTML response: %w", ErrUnexpectedResponse, err)
 	}
 
 	var csrf string
@@ -158,9 +431,12 @@ func (c *Client) ObtainAntiCSRF(ctx context.Context) (string, error) {
 	f(z)
 
 	if csrf == "" {
-		return "", fmt.Errorf("failed to find csrf value in HTML document")
+		return "", c.loginPageError(ctx, op, resp, body, classifyLoginPage(resp, body))
 	}
 
+	c.secrets.pin(secretAntiCSRF, csrf)
+	c.logger().DebugContext(ctx, "anticsrf obtained")
+
 	return csrf, nil
 }
 
@@ -170,100 +446,144 @@ type LoginResponse struct {
 	Error    string `json:"error"`
 }
 
-// Login logs into the Cronometer and the GWT API. Nil is returned on login success.
+// Login logs into the Cronometer and the GWT API. Nil is returned on login success. If the account requires a second
+// factor the code is requested from the TwoFactor function of the client. If the client has a session store the new
+// session is saved to it.
 func (c *Client) Login(ctx context.Context, username string, password string) error {
 	// Obtaining a new anticsrf from the login page.
 	antiCSRF, err := c.ObtainAntiCSRF(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve anit CSRF: %s", err)
+		return fmt.Errorf("failed to retrieve anit CSRF: %w", err)
 	}
 
+	c.secrets.pin(secretPassword, password)
+	reportProgress(ctx, Progress{Phase: PhaseAuthenticating})
+
 	// Building login request.
 	formData := url.Values{}
 	formData.Set("anticsrf", antiCSRF)
 	formData.Set("password", password)
 	formData.Set("username", username)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", APILoginURL, strings.NewReader(formData.Encode()))
+	req, err := http.NewRequestWithContext(withOperation(ctx, OpLogin), "POST", c.APILoginURL, strings.NewReader(formData.Encode()))
 	if err != nil {
-		return fmt.Errorf("failed while building http request for login: %s", err)
+		return fmt.Errorf("failed while building http request for login: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed while executing http request for login: %s", err)
+		return fmt.Errorf("failed while executing http request for login: %w", err)
 	}
 	defer closeAndExhaustReader(resp.Body)
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("received non 200 response of %d for login", resp.StatusCode)
+		return &StatusError{Op: "login", StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read body of login response: %s", err)
+		return fmt.Errorf("failed to read body of login response: %w", err)
 	}
 
 	var loginResponse LoginResponse
 	if err = json.Unmarshal(body, &loginResponse); err != nil {
-		return fmt.Errorf("failed to unmarshal login response json: %s", err)
+		return fmt.Errorf("%w: failed to unmarshal login response json: %w", ErrUnexpectedResponse, err)
 	}
 
 	if loginResponse.Error != "" {
-		return fmt.Errorf("failed to login: %s", loginResponse.Error)
+		c.logger().WarnContext(ctx, "login rejected", slog.String("message", loginResponse.Error))
+		return &LoginError{Message: loginResponse.Error}
 	}
 
-	// Storing the nonce from provided cookies.
-	c.updateSesnonce(resp)
+	// The nonce is kept until the session is authenticated with GWT so it is only published along with the user ID.
+	var nonce string
+	if loginResponse.twoFactorRequired() {
+		// The nonce is provided once the second factor is accepted.
+		if nonce, err = c.completeTwoFactor(ctx, resp.Request.URL, loginResponse.Redirect); err != nil {
+			return err
+		}
+	} else {
+		nonce = c.sesnonce(resp)
+
+		// A login that neither completed nor asked for a second factor would otherwise only fail once GWT reports the
+		// session as not logged in.
+		if !loginResponse.Success && nonce == "" {
+			return fmt.Errorf("%w: login was not completed and redirected to %q", ErrUnexpectedResponse,
+				c.Redact(loginResponse.Redirect))
+		}
+	}
+	c.logger().DebugContext(ctx, "login posted")
 
 	// Authenticating with GWT.
-	err = c.GWTAuthenticate(ctx)
+	err = c.gwtAuthenticate(ctx, nonce)
 	if err != nil {
-		return fmt.Errorf("failed to authenticate with GWT: %s", err)
+		return fmt.Errorf("failed to authenticate with GWT: %w", err)
+	}
+
+	c.sessionGen.Add(1)
+	c.logger().InfoContext(ctx, "logged in", slog.String("userid", c.SessionUserID()))
+
+	if c.SessionStore != nil {
+		if err = c.SaveSessionTo(ctx, c.SessionStore); err != nil {
+			return fmt.Errorf("logged in but failed to save session: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func (c *Client) updateSesnonce(resp *http.Response) {
+// sesnonce returns the value of the sesnonce cookie set by the response or an empty string if it sets none.
+func (c *Client) sesnonce(resp *http.Response) string {
 	if resp == nil {
-		return
+		return ""
 	}
 
-	cookies := resp.Cookies()
-	for _, cookie := range cookies {
+	nonce := ""
+	for _, cookie := range resp.Cookies() {
 		if cookie.Name == "sesnonce" {
-			c.Nonce = cookie.Value
+			nonce = cookie.Value
+			c.secrets.pin(secretNonce, nonce)
 		}
 	}
+	return nonce
 }
 
 // Logout logs out from the API.
 func (c *Client) Logout(ctx context.Context) error {
-	// Building the request.
-	reqBody := fmt.Sprintf(GWTLogout, c.Nonce)
-
-	req, err := c.NewGWTRequestWithContext(ctx, "POST", GWTBaseURL, strings.NewReader(reqBody))
-	if err != nil {
-		return fmt.Errorf("failed while building http request for gwt authentication: %s", err)
+	if err := c.ensureGWTValues(ctx); err != nil {
+		return err
 	}
 
+	// Building the request.
+	st := c.state()
+	reqBody := st.gwtRequestBody(gwtLogoutCall(st.nonce))
+
 	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doGWTRequest(ctx, OpGWTLogout, st, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed while executing http request for gwt logout: %s", err)
+		return fmt.Errorf("failed while executing http request for gwt logout: %w", err)
 	}
 	//noinspection GoUnhandledErrorResult
 	defer closeAndExhaustReader(resp.Body)
 
 	// Handling the response.
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("received non 200 response of %d for gwt logout", resp.StatusCode)
+		return &StatusError{Op: "gwt logout", StatusCode: resp.StatusCode}
 	}
 
-	c.UserID = ""
-	c.Nonce = ""
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body of gwt logout response: %w", err)
+	}
+
+	if _, err = c.decodeGWTResponse("logout", body); err != nil {
+		return err
+	}
+
+	c.setSession("", "")
+	c.dropExportToken()
+	c.logger().InfoContext(ctx, "logged out")
 
 	return nil
 }
@@ -271,325 +591,175 @@ func (c *Client) Logout(ctx context.Context) error {
 // GWTAuthenticate will authenticate with the GWT API using the sesnonce of the client. Login() calls this by default so
 // in most cases this should never be called directly.
 func (c *Client) GWTAuthenticate(ctx context.Context) error {
-	// Building and sending the request.
-	//reqBody := fmt.Sprintf(GWTAuthenticate, c.Nonce)
+	return c.gwtAuthenticate(ctx, c.SessionNonce())
+}
 
-	req, err := c.NewGWTRequestWithContext(ctx, "POST", GWTBaseURL, strings.NewReader(GWTAuthenticate))
-	if err != nil {
-		return fmt.Errorf("failed while building http request for gwt authentication: %s", err)
+// gwtAuthenticate authenticates the session of the cookie jar with the GWT API. The nonce of the session, replaced by
+// the sesnonce cookie of the response if it sets one, is published along with the user ID once authenticated so
+// requests never see the nonce of one session with the user ID of another.
+func (c *Client) gwtAuthenticate(ctx context.Context, nonce string) error {
+	if err := c.ensureGWTValues(ctx); err != nil {
+		return err
 	}
 
+	// Building and sending the request.
+	st := c.state()
+	reqBody := st.gwtRequestBody(gwtAuthenticateCall(int32(c.gwtTimezoneOffset())))
+
 	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doGWTRequest(ctx, OpGWTAuthenticate, st, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed while executing http request for gwt authentication: %s", err)
+		return fmt.Errorf("failed while executing http request for gwt authentication: %w", err)
 	}
 	defer closeAndExhaustReader(resp.Body)
 
 	// Handling the response.
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("received non 200 response of %d for gwt token generation", resp.StatusCode)
+		return &StatusError{Op: "gwt authentication", StatusCode: resp.StatusCode}
 	}
 
-	c.updateSesnonce(resp)
+	if refreshed := c.sesnonce(resp); refreshed != "" {
+		nonce = refreshed
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read body of gwt token authentication: %s", err)
+		return fmt.Errorf("failed to read body of gwt token authentication: %w", err)
 	}
 
-	match := GWTAuthenticationRegexp.FindStringSubmatch(string(body))
+	r, err := c.decodeGWTResponse("authenticate", body)
+	if err != nil {
+		return err
+	}
 
-	if len(match) != 2 {
-		return fmt.Errorf("failed to find GWT Authentication token in response data, expected 2 matches but received %d", len(match))
+	userID, err := r.ReadInt()
+	if err == nil {
+		err = r.End()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read user id from gwt authentication response: %w", err)
 	}
 
-	c.UserID = match[1]
+	c.setSession(nonce, strconv.Itoa(int(userID)))
+	c.logger().DebugContext(ctx, "gwt authenticated", slog.Int("userid", int(userID)))
 
 	return nil
 }
 
 // GenerateAuthToken requests an authentication token from the API. This token is used to request the generation of
-// a "token" that is provided as a nonce to the export API calls.
+// a "token" that is provided as a nonce to the export API calls. A new token with the TokenLifetime and AuthScope of
+// the client is requested on every call. Exports reuse a cached token instead.
 func (c *Client) GenerateAuthToken(ctx context.Context) (string, error) {
+	var token string
+	err := c.withSessionRenewal(ctx, func() (err error) {
+		token, err = c.generateAuthToken(ctx)
+		return err
+	})
 
-	// Building the request.
-	reqBody := fmt.Sprintf(GWTGenerateAuthToken, c.Nonce, c.UserID)
+	return token, err
+}
+
+// generateAuthToken is GenerateAuthToken without session renewal.
+func (c *Client) generateAuthToken(ctx context.Context) (string, error) {
+	if err := c.ensureGWTValues(ctx); err != nil {
+		return "", err
+	}
 
-	req, err := c.NewGWTRequestWithContext(ctx, "POST", GWTBaseURL, strings.NewReader(reqBody))
+	// Building the request.
+	st := c.state()
+	if st.userID == "" {
+		return "", fmt.Errorf("%w: client has no user id", ErrSessionExpired)
+	}
+	userID, err := strconv.ParseInt(st.userID, 10, 32)
 	if err != nil {
-		return "", fmt.Errorf("failed while building http request for gwt token generation: %s", err)
+		return "", fmt.Errorf("%w: user id %q is not numeric", ErrUnexpectedResponse, st.userID)
 	}
+	reqBody := st.gwtRequestBody(gwtGenerateAuthTokenCall(st.nonce, int32(userID), int32(c.tokenLifetime().Seconds()), c.AuthScope))
 
 	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.doGWTRequest(ctx, OpGWTGenerateToken, st, reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for gwt token generation: %s", err)
+		return "", fmt.Errorf("failed while executing http request for gwt token generation: %w", err)
 	}
 	//noinspection GoUnhandledErrorResult
 	defer closeAndExhaustReader(resp.Body)
 
 	// Handling the response.
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for gwt token generation", resp.StatusCode)
+		return "", &StatusError{Op: "gwt token generation", StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read body of gwt token generation response: %s", err)
+		return "", fmt.Errorf("failed to read body of gwt token generation response: %w", err)
 	}
 
-	match := GWTTokenRegex.FindStringSubmatch(string(body))
-
-	if len(match) != 2 {
-		return "", fmt.Errorf("failed to find token in response data, expected 2 matches but received %d", len(match))
-	}
-
-	return match[1], nil
-}
-
-// ExportDailyNutrition exports the daily nutrition values within the date range. Only the YYYY-mm-dd is utilized of startDate and
-// endDate. The export is the raw string data.
-func (c *Client) ExportDailyNutrition(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
-	// Generating the required token.
-	token, err := c.GenerateAuthToken(ctx)
+	r, err := c.decodeGWTResponse("generateAuthorizationToken", body)
 	if err != nil {
-		return "", fmt.Errorf("failed to get token to make request: %s", err)
+		return "", err
 	}
 
-	// Building the request.
-	req, err := c.NewExportRequest(ctx, "GET", APIExportURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed while building http request for daily nutrition export: %s", err)
+	token, err := r.ReadString()
+	if err == nil {
+		err = r.End()
 	}
-
-	q := req.URL.Query()
-	q.Add("nonce", token)
-	q.Add("generate", "dailySummary")
-	q.Add("start", startDate.Format("2006-01-02"))
-	q.Add("end", endDate.Format("2006-01-02"))
-	req.URL.RawQuery = q.Encode()
-
-	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for daily nutrition export: %s", err)
+		return "", fmt.Errorf("failed to read token from gwt token generation response: %w", err)
 	}
-	//noinspection GoUnhandledErrorResult
-	defer closeAndExhaustReader(resp.Body)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read body of daily nutrition export response: %s", err)
+	if token == "" {
+		return "", fmt.Errorf("%w: gwt token generation response has no token", ErrUnexpectedResponse)
 	}
 
-	// Handling the response.
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for daily nutrition export: body %s", resp.StatusCode, string(body))
-	}
+	c.secrets.add(token)
+	c.logger().DebugContext(ctx, "export token generated")
 
-	return string(body), nil
+	return token, nil
+}
+
+// ExportDailyNutrition exports the daily nutrition values within the date range. Only the YYYY-mm-dd is utilized of startDate and
+// endDate. The export is the raw string data.
+func (c *Client) ExportDailyNutrition(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
+	return c.Export(ctx, ExportKindDailyNutrition, startDate, endDate)
 }
 
 // ExportServings exports all the services within the date range. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is the raw string data.
 func (c *Client) ExportServings(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
-
-	// Generating the required token.
-	token, err := c.GenerateAuthToken(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get token to make request: %s", err)
-	}
-
-	// Building the request.
-	req, err := c.NewExportRequest(ctx, "GET", APIExportURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed while building http request for servings export: %s", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("nonce", token)
-	q.Add("generate", "servings")
-	q.Add("start", startDate.Format("2006-01-02"))
-	q.Add("end", endDate.Format("2006-01-02"))
-	req.URL.RawQuery = q.Encode()
-
-	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for servings export: %s", err)
-	}
-	//noinspection GoUnhandledErrorResult
-	defer closeAndExhaustReader(resp.Body)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read body of servings export response: %s", err)
-	}
-
-	// Handling the response.
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for servings export: body [%s]", resp.StatusCode, string(body))
-	}
-
-	return string(body), nil
-
+	return c.Export(ctx, ExportKindServings, startDate, endDate)
 }
 
 // ExportExercises exports the exercises within the date range. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is the raw string data.
 func (c *Client) ExportExercises(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
-	// Generating the required token.
-	token, err := c.GenerateAuthToken(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get token to make request: %s", err)
-	}
-
-	// Building the request.
-	req, err := c.NewExportRequest(ctx, "GET", APIExportURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed while building http request for exercises export: %s", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("nonce", token)
-	q.Add("generate", "exercises")
-	q.Add("start", startDate.Format("2006-01-02"))
-	q.Add("end", endDate.Format("2006-01-02"))
-	req.URL.RawQuery = q.Encode()
-
-	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for exercises export: %s", err)
-	}
-	//noinspection GoUnhandledErrorResult
-	defer closeAndExhaustReader(resp.Body)
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read body of exercises export response: %s", err)
-	}
-
-	// Handling the response.
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for exercises export: body %s", resp.StatusCode, string(body))
-	}
-
-	return string(body), nil
-}
-
-// NewExportRequest creates a new http request for exports.
-func (c *Client) NewExportRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("sec-fetch-dest", "document")
-	req.Header.Add("sec-fetch-mode", "navigate")
-	req.Header.Add("sec-fetch-site", "same-origin")
-
-	return req, nil
+	return c.Export(ctx, ExportKindExercises, startDate, endDate)
 }
 
 // ExportBiometrics exports the biometrics within the date range. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is the raw string data.
 func (c *Client) ExportBiometrics(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
-	// Generating the required token.
-	token, err := c.GenerateAuthToken(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get token to make request: %s", err)
-	}
-
-	// Building the request.
-	req, err := c.NewExportRequest(ctx, "GET", APIExportURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed while building http request for biometrics export: %s", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("nonce", token)
-	q.Add("generate", "biometrics")
-	q.Add("start", startDate.Format("2006-01-02"))
-	q.Add("end", endDate.Format("2006-01-02"))
-	req.URL.RawQuery = q.Encode()
-
-	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for biometrics export: %s", err)
-	}
-	//noinspection GoUnhandledErrorResult
-	defer closeAndExhaustReader(resp.Body)
-
-	// Handling the response.
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for biometrics export", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read body of biometrics export response: %s", err)
-	}
-
-	return string(body), nil
+	return c.Export(ctx, ExportKindBiometrics, startDate, endDate)
 }
 
 // ExportNotes exports the notes within the date range. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is the raw string data.
 func (c *Client) ExportNotes(ctx context.Context, startDate time.Time, endDate time.Time) (string, error) {
-	// Generating the required token.
-	token, err := c.GenerateAuthToken(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get token to make request: %s", err)
-	}
-
-	// Building the request.
-	req, err := c.NewExportRequest(ctx, "GET", APIExportURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed while building http request for notes export: %s", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("nonce", token)
-	q.Add("generate", "notes")
-	q.Add("start", startDate.Format("2006-01-02"))
-	q.Add("end", endDate.Format("2006-01-02"))
-	req.URL.RawQuery = q.Encode()
-
-	// Executing the request.
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed while executing http request for notes export: %s", err)
-	}
-	//noinspection GoUnhandledErrorResult
-	defer closeAndExhaustReader(resp.Body)
-
-	// Handling the response.
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("received non 200 response of %d for notes export", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read body of notes export response: %s", err)
-	}
-
-	return string(body), nil
+	return c.Export(ctx, ExportKindNotes, startDate, endDate)
 }
 
 // ExportServingsParsed exports the servings within the date range and parses them into a go struct. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is the raw string data.
 func (c *Client) ExportServingsParsed(ctx context.Context, startDate time.Time, endDate time.Time) (ServingRecords, error) {
-	raw, err := c.ExportServings(ctx, startDate, endDate)
+	body, err := c.ExportStream(ctx, ExportKindServings, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("retreiving raw data: %s", err)
+		return nil, fmt.Errorf("retreiving raw data: %w", err)
 	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
 
-	servings, err := ParseServingsExport(strings.NewReader(raw), time.UTC)
+	servings, err := parseServingsExport(body, time.UTC,
+		parseProgress(ctx, ExportKindServings, DateWindow{Start: startDate, End: endDate}))
 	if err != nil {
-		return nil, fmt.Errorf("parsing raw data: %s", err)
+		return nil, fmt.Errorf("parsing raw data: %w", err)
 	}
 
 	return servings, nil
@@ -598,14 +768,17 @@ func (c *Client) ExportServingsParsed(ctx context.Context, startDate time.Time,
 // ExportServingsParsedWithLocation is the same as ExportServingsParsed but sets the location of every recorded time
 // to the location provided.
 func (c *Client) ExportServingsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (ServingRecords, error) {
-	raw, err := c.ExportServings(ctx, startDate, endDate)
+	body, err := c.ExportStream(ctx, ExportKindServings, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("retreiving raw data: %s", err)
+		return nil, fmt.Errorf("retreiving raw data: %w", err)
 	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
 
-	servings, err := ParseServingsExport(strings.NewReader(raw), location)
+	servings, err := parseServingsExport(body, location,
+		parseProgress(ctx, ExportKindServings, DateWindow{Start: startDate, End: endDate}))
 	if err != nil {
-		return nil, fmt.Errorf("parsing raw data: %s", err)
+		return nil, fmt.Errorf("parsing raw data: %w", err)
 	}
 
 	return servings, nil
@@ -614,14 +787,17 @@ func (c *Client) ExportServingsParsedWithLocation(ctx context.Context, startDate
 // ExportExercisesParsedWithLocation exports the exercises within the date range and parses them into a go struct. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is parsed and dates set to the location provided.
 func (c *Client) ExportExercisesParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (ExerciseRecords, error) {
-	raw, err := c.ExportExercises(ctx, startDate, endDate)
+	body, err := c.ExportStream(ctx, ExportKindExercises, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("retreiving raw data: %s", err)
+		return nil, fmt.Errorf("retreiving raw data: %w", err)
 	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
 
-	exercises, err := ParseExerciseExport(strings.NewReader(raw), location)
+	exercises, err := parseExerciseExport(body, location,
+		parseProgress(ctx, ExportKindExercises, DateWindow{Start: startDate, End: endDate}))
 	if err != nil {
-		return nil, fmt.Errorf("parsing raw data: %s", err)
+		return nil, fmt.Errorf("parsing raw data: %w", err)
 	}
 
 	return exercises, nil
@@ -642,14 +818,17 @@ func closeAndExhaustReader(r io.ReadCloser) {
 // ExportBiometricRecordsParsedWithLocation exports the biometric records within the date range and parses them into a go struct. Only the YYYY-mm-dd is utilized of startDate and
 // endDate. The export is parsed and dates set to the location provided.
 func (c *Client) ExportBiometricRecordsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (BiometricRecords, error) {
-	raw, err := c.ExportBiometrics(ctx, startDate, endDate)
+	body, err := c.ExportStream(ctx, ExportKindBiometrics, startDate, endDate)
 	if err != nil {
-		return nil, fmt.Errorf("retreiving raw data: %s", err)
+		return nil, fmt.Errorf("retreiving raw data: %w", err)
 	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
 
-	exercises, err := ParseBiometricRecordsExport(strings.NewReader(raw), location)
+	exercises, err := parseBiometricRecordsExport(body, location,
+		parseProgress(ctx, ExportKindBiometrics, DateWindow{Start: startDate, End: endDate}))
 	if err != nil {
-		return nil, fmt.Errorf("parsing raw data: %s", err)
+		return nil, fmt.Errorf("parsing raw data: %w", err)
 	}
 
 	return exercises, nil
@@ -3,20 +3,25 @@ package gocronometer_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
 )
 
-// setup perform some basic actions to setup testing.
-func setup() (username string, password string, client *gocronometer.Client, err error) {
+// setup perform some basic actions to setup testing. When GOCRONOMETER_TEST_USERNAME is not set the client is
+// directed at a fake server seeded with a small diary instead of the live API.
+func setup(t *testing.T) (username string, password string, client *gocronometer.Client, err error) {
 	username = os.Getenv("GOCRONOMETER_TEST_USERNAME")
 	password = os.Getenv("GOCRONOMETER_TEST_PASSWORD")
 
 	if username == "" {
-		return "", "", nil, fmt.Errorf("username is empty, is GOCRONOMETER_TEST_USERNAME set")
+		srv := newTestServer(t)
+		return gocronometertest.DefaultUsername, gocronometertest.DefaultPassword, srv.NewClient(), nil
 	}
 
 	if password == "" {
@@ -26,8 +31,53 @@ func setup() (username string, password string, client *gocronometer.Client, err
 	return username, password, gocronometer.NewClient(nil), nil
 }
 
+// newTestServer starts a fake server seeded with a few days of diary data in June 2021. The server is closed when the
+// test completes.
+func newTestServer(t *testing.T) *gocronometertest.Server {
+	srv := gocronometertest.NewServer()
+	t.Cleanup(srv.Close)
+
+	day := time.Date(2021, 6, 2, 8, 30, 0, 0, time.UTC)
+	srv.AddServings(
+		gocronometer.ServingRecord{RecordedTime: day, Group: "Breakfast", FoodName: "Oatmeal", QuantityValue: 1,
+			QuantityUnits: "cup", EnergyKcal: 150, CarbsG: 27, ProteinG: 5, Category: "Cereals"},
+		gocronometer.ServingRecord{RecordedTime: day.Add(28 * time.Hour), Group: "Lunch", FoodName: "Apple",
+			QuantityValue: 1, QuantityUnits: "medium", EnergyKcal: 95, CarbsG: 25, Category: "Fruits"},
+	)
+	srv.AddExercises(gocronometer.ExerciseRecord{RecordedTime: day.Add(10 * time.Hour), Exercise: "Walking",
+		Minutes: 30, CaloriesBurned: 120, Group: "Exercise"})
+	srv.AddBiometrics(gocronometer.BiometricRecord{RecordedTime: day, Metric: "Weight", Unit: "kg", Amount: 72.5})
+	srv.AddNotes(gocronometertest.Note{Day: day, Group: "Breakfast", Note: "Felt great"})
+
+	return srv
+}
+
+// testClient starts a fake server with newTestServer and returns it with a client of it. The options of the client
+// are modified by configure, if not nil, before it is created.
+func testClient(t *testing.T, configure func(opts *gocronometer.ClientOptions)) (*gocronometertest.Server, *gocronometer.Client) {
+	srv := newTestServer(t)
+
+	opts := srv.ClientOptions()
+	if configure != nil {
+		configure(opts)
+	}
+
+	return srv, gocronometer.NewClient(opts)
+}
+
+// loggedInClient is testClient with the client logged into the fake server.
+func loggedInClient(t *testing.T, configure func(opts *gocronometer.ClientOptions)) (*gocronometertest.Server, *gocronometer.Client) {
+	srv, client := testClient(t, configure)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	return srv, client
+}
+
 func TestClient_ObtainAntiCSRF(t *testing.T) {
-	_, _, client, err := setup()
+	_, _, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -43,7 +93,7 @@ func TestClient_ObtainAntiCSRF(t *testing.T) {
 }
 
 func TestClient_Login(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -56,7 +106,7 @@ func TestClient_Login(t *testing.T) {
 }
 
 func TestClient_Login_BadCreds(t *testing.T) {
-	username, _, client, err := setup()
+	username, _, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -67,7 +117,7 @@ func TestClient_Login_BadCreds(t *testing.T) {
 }
 
 func TestClient_GenerateAuthToken(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -93,7 +143,7 @@ func TestClient_GenerateAuthToken(t *testing.T) {
 }
 
 func TestClient_ExportBiometrics(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -115,7 +165,7 @@ func TestClient_ExportBiometrics(t *testing.T) {
 }
 
 func TestClient_ExportDailyNutrition(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -136,7 +186,7 @@ func TestClient_ExportDailyNutrition(t *testing.T) {
 }
 
 func TestClient_ExportNotes(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -158,7 +208,7 @@ func TestClient_ExportNotes(t *testing.T) {
 }
 
 func TestClient_ExportServings(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -180,7 +230,7 @@ func TestClient_ExportServings(t *testing.T) {
 }
 
 func TestClient_ExportExercises(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -201,7 +251,7 @@ func TestClient_ExportExercises(t *testing.T) {
 }
 
 func TestClient_ExportServingsParsed(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -223,7 +273,7 @@ func TestClient_ExportServingsParsed(t *testing.T) {
 }
 
 func TestClient_ExportDailyNutritionParsed(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -245,7 +295,7 @@ func TestClient_ExportDailyNutritionParsed(t *testing.T) {
 }
 
 func TestClient_ExportExercisesParsed(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -267,7 +317,7 @@ func TestClient_ExportExercisesParsed(t *testing.T) {
 }
 
 func TestClient_ExportBiometricRecordsParsed(t *testing.T) {
-	username, password, client, err := setup()
+	username, password, client, err := setup(t)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -287,3 +337,31 @@ func TestClient_ExportBiometricRecordsParsed(t *testing.T) {
 	}
 
 }
+
+func TestClient_BaseURL(t *testing.T) {
+	paths := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		fmt.Fprint(w, `<html><body><form><input name="anticsrf" value="local-csrf"></form></body></html>`)
+	}))
+	defer srv.Close()
+
+	client := gocronometer.NewClient(&gocronometer.ClientOptions{BaseURL: srv.URL + "/"})
+
+	if client.GWTModuleBase != srv.URL+"/cronometer/" {
+		t.Fatalf("expected module base to be built from the base URL but found %s", client.GWTModuleBase)
+	}
+
+	antiCSRF, err := client.ObtainAntiCSRF(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if antiCSRF != "local-csrf" {
+		t.Fatalf("expected anticsrf of local-csrf but found %s", antiCSRF)
+	}
+
+	if path := <-paths; path != "/login/" {
+		t.Fatalf("expected request to /login/ but found %s", path)
+	}
+}
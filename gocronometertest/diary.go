@@ -0,0 +1,187 @@
+package gocronometertest
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+)
+
+// The following are the layouts the exports utilize for dates and times.
+const (
+	dayLayout  = "2006-01-02"
+	timeLayout = "3:04 PM"
+)
+
+// inRange reports if the day of t falls within the start and end days inclusively.
+func inRange(t time.Time, start time.Time, end time.Time) bool {
+	day := t.Format(dayLayout)
+	return day >= start.Format(dayLayout) && day <= end.Format(dayLayout)
+}
+
+// formatFloat formats f in the shortest form that parses back to the same value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// writeCSV writes the header and rows to w as CSV.
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeServings writes the servings export for the servings within the date range.
+func writeServings(w io.Writer, servings gocronometer.ServingRecords, start time.Time, end time.Time) error {
+	header := []string{"Day", "Time", "Group", "Food Name", "Amount", "Energy (kcal)", "Carbs (g)", "Fiber (g)",
+		"Fat (g)", "Protein (g)", "Sodium (mg)", "Category"}
+
+	servings = append(gocronometer.ServingRecords(nil), servings...)
+	sort.SliceStable(servings, func(i, j int) bool { return servings[i].RecordedTime.Before(servings[j].RecordedTime) })
+
+	rows := make([][]string, 0, len(servings))
+	for _, s := range servings {
+		if !inRange(s.RecordedTime, start, end) {
+			continue
+		}
+		rows = append(rows, []string{
+			s.RecordedTime.Format(dayLayout),
+			s.RecordedTime.Format(timeLayout),
+			s.Group,
+			s.FoodName,
+			formatFloat(s.QuantityValue) + " " + s.QuantityUnits,
+			formatFloat(s.EnergyKcal),
+			formatFloat(s.CarbsG),
+			formatFloat(s.FiberG),
+			formatFloat(s.FatG),
+			formatFloat(s.ProteinG),
+			formatFloat(s.SodiumMg),
+			s.Category,
+		})
+	}
+
+	return writeCSV(w, header, rows)
+}
+
+// writeDailySummary writes the daily nutrition export summarizing the servings within the date range.
+func writeDailySummary(w io.Writer, servings gocronometer.ServingRecords, start time.Time, end time.Time) error {
+	header := []string{"Date", "Energy (kcal)", "Carbs (g)", "Fiber (g)", "Fat (g)", "Protein (g)", "Sodium (mg)",
+		"Completed"}
+
+	days := make(map[string]*gocronometer.ServingRecord)
+	for _, s := range servings {
+		if !inRange(s.RecordedTime, start, end) {
+			continue
+		}
+		day := s.RecordedTime.Format(dayLayout)
+		total, ok := days[day]
+		if !ok {
+			total = &gocronometer.ServingRecord{}
+			days[day] = total
+		}
+		total.EnergyKcal += s.EnergyKcal
+		total.CarbsG += s.CarbsG
+		total.FiberG += s.FiberG
+		total.FatG += s.FatG
+		total.ProteinG += s.ProteinG
+		total.SodiumMg += s.SodiumMg
+	}
+
+	keys := make([]string, 0, len(days))
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, 0, len(keys))
+	for _, day := range keys {
+		t := days[day]
+		rows = append(rows, []string{
+			day,
+			formatFloat(t.EnergyKcal),
+			formatFloat(t.CarbsG),
+			formatFloat(t.FiberG),
+			formatFloat(t.FatG),
+			formatFloat(t.ProteinG),
+			formatFloat(t.SodiumMg),
+			"false",
+		})
+	}
+
+	return writeCSV(w, header, rows)
+}
+
+// writeExercises writes the exercises export for the exercises within the date range.
+func writeExercises(w io.Writer, exercises gocronometer.ExerciseRecords, start time.Time, end time.Time) error {
+	header := []string{"Day", "Time", "Group", "Exercise", "Minutes", "Calories Burned"}
+
+	exercises = append(gocronometer.ExerciseRecords(nil), exercises...)
+	sort.SliceStable(exercises, func(i, j int) bool { return exercises[i].RecordedTime.Before(exercises[j].RecordedTime) })
+
+	rows := make([][]string, 0, len(exercises))
+	for _, e := range exercises {
+		if !inRange(e.RecordedTime, start, end) {
+			continue
+		}
+		rows = append(rows, []string{
+			e.RecordedTime.Format(dayLayout),
+			e.RecordedTime.Format(timeLayout),
+			e.Group,
+			e.Exercise,
+			formatFloat(e.Minutes),
+			formatFloat(e.CaloriesBurned),
+		})
+	}
+
+	return writeCSV(w, header, rows)
+}
+
+// writeBiometrics writes the biometrics export for the biometrics within the date range.
+func writeBiometrics(w io.Writer, biometrics gocronometer.BiometricRecords, start time.Time, end time.Time) error {
+	header := []string{"Day", "Time", "Metric", "Unit", "Amount"}
+
+	biometrics = append(gocronometer.BiometricRecords(nil), biometrics...)
+	sort.SliceStable(biometrics, func(i, j int) bool { return biometrics[i].RecordedTime.Before(biometrics[j].RecordedTime) })
+
+	rows := make([][]string, 0, len(biometrics))
+	for _, b := range biometrics {
+		if !inRange(b.RecordedTime, start, end) {
+			continue
+		}
+		rows = append(rows, []string{
+			b.RecordedTime.Format(dayLayout),
+			b.RecordedTime.Format(timeLayout),
+			b.Metric,
+			b.Unit,
+			formatFloat(b.Amount),
+		})
+	}
+
+	return writeCSV(w, header, rows)
+}
+
+// writeNotes writes the notes export for the notes within the date range.
+func writeNotes(w io.Writer, notes []Note, start time.Time, end time.Time) error {
+	header := []string{"Day", "Group", "Note"}
+
+	notes = append([]Note(nil), notes...)
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Day.Before(notes[j].Day) })
+
+	rows := make([][]string, 0, len(notes))
+	for _, n := range notes {
+		if !inRange(n.Day, start, end) {
+			continue
+		}
+		rows = append(rows, []string{n.Day.Format(dayLayout), n.Group, n.Note})
+	}
+
+	return writeCSV(w, header, rows)
+}
@@ -0,0 +1,37 @@
+package gocronometertest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// The following are the strong names the bootstrap maps the user agents not utilized for discovery to.
+const (
+	ie10Permutation  = "0F4C8D8C2E1A6B5B90B7A5C3B3F1E2D4"
+	otherPermutation = "9A1D7E3C5B8F2A4E6C0D1B3F5A7E9C2D"
+)
+
+// handleGWTScript serves the nocache.js bootstrap and the cache.js of the current permutation in the shape produced by
+// the GWT xsiframe linker.
+func (s *Server) handleGWTScript(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/javascript")
+
+	switch r.PathValue("script") {
+	case "cronometer.nocache.js":
+		// The obfuscated bootstrap assigns every string literal to a variable before mapping the property values.
+		fmt.Fprintf(w, `function cronometer(){var O='bootstrap',P='begin',Q='gwt.codesvr.cronometer=',`+
+			`Lb='ie10',Mb='gecko1_8',Nb='safari',Ob='default',Pb='%s',Qb='%s',$b='%s';`+
+			`function R(a,b){var c=T;for(var d=0,e=a.length-1;d<e;++d){c=c[a[d]]||(c[a[d]]=[])}c[a[e]]=b}`+
+			`R([Lb,Ob],Pb);R([Mb,Ob],Qb);R([Nb,Ob],$b);`+
+			`U=V+'.cache.js';}cronometer();`, ie10Permutation, otherPermutation, s.permutation)
+	case s.permutation + ".cache.js":
+		fmt.Fprintf(w, `cronometer.onScriptDownloaded(["var $wnd = $wnd || window.parent;`+
+			`function Xb(){Yb.call(this,Zb(),\'login\',\'%s\',$b)}`+
+			`function Wb(){Yb.call(this,Zb(),\'app\',\'%s\',$c)}"]);`, otherPermutation, s.policy)
+	default:
+		http.NotFound(w, r)
+	}
+}
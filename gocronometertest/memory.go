@@ -0,0 +1,222 @@
+package gocronometertest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+)
+
+// MemoryClient is an in-memory gocronometer.Exporter backed by seedable diary data. It follows the contract of the
+// client without any HTTP: exports require a login, the raw data is the CSV the export API produces and the parsed
+// data is that CSV parsed by the gocronometer parsers. Errors can be set per method and export kind. A MemoryClient
+// is safe for concurrent use and the zero value is not usable, a new one should be created with NewMemoryClient.
+type MemoryClient struct {
+	mu sync.Mutex
+
+	username string
+	password string
+	loggedIn bool
+
+	servings   gocronometer.ServingRecords
+	exercises  gocronometer.ExerciseRecords
+	biometrics gocronometer.BiometricRecords
+	notes      []Note
+
+	loginErr   error
+	logoutErr  error
+	exportErrs map[gocronometer.ExportKind]error
+}
+
+var _ gocronometer.Exporter = (*MemoryClient)(nil)
+
+// NewMemoryClient returns a new logged out MemoryClient accepting the default credentials.
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{
+		username:   DefaultUsername,
+		password:   DefaultPassword,
+		exportErrs: make(map[gocronometer.ExportKind]error),
+	}
+}
+
+// SetCredentials sets the username and password the client accepts.
+func (m *MemoryClient) SetCredentials(username string, password string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.username = username
+	m.password = password
+}
+
+// AddServings seeds the diary with the servings provided.
+func (m *MemoryClient) AddServings(records ...gocronometer.ServingRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servings = append(m.servings, records...)
+}
+
+// AddExercises seeds the diary with the exercises provided.
+func (m *MemoryClient) AddExercises(records ...gocronometer.ExerciseRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exercises = append(m.exercises, records...)
+}
+
+// AddBiometrics seeds the diary with the biometrics provided.
+func (m *MemoryClient) AddBiometrics(records ...gocronometer.BiometricRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.biometrics = append(m.biometrics, records...)
+}
+
+// AddNotes seeds the diary with the notes provided.
+func (m *MemoryClient) AddNotes(notes ...Note) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notes = append(m.notes, notes...)
+}
+
+// SetLoginError causes every login to fail with err. A nil err restores the credential check.
+func (m *MemoryClient) SetLoginError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loginErr = err
+}
+
+// SetLogoutError causes every logout to fail with err.
+func (m *MemoryClient) SetLogoutError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logoutErr = err
+}
+
+// SetExportError causes every raw and parsed export of the kind to fail with err. A nil err removes the error.
+func (m *MemoryClient) SetExportError(kind gocronometer.ExportKind, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.exportErrs, kind)
+		return
+	}
+	m.exportErrs[kind] = err
+}
+
+// ExpireSession logs the client out as if the session had expired.
+func (m *MemoryClient) ExpireSession() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loggedIn = false
+}
+
+// Login logs in if the credentials match. Invalid credentials return a gocronometer.LoginError.
+func (m *MemoryClient) Login(_ context.Context, username string, password string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.loginErr != nil {
+		return m.loginErr
+	}
+	if username != m.username || password != m.password {
+		return &gocronometer.LoginError{Message: "Invalid email or password"}
+	}
+
+	m.loggedIn = true
+	return nil
+}
+
+// Logout logs out.
+func (m *MemoryClient) Logout(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.logoutErr != nil {
+		return m.logoutErr
+	}
+
+	m.loggedIn = false
+	return nil
+}
+
+// Export returns the CSV data of the kind for the date range.
+func (m *MemoryClient) Export(ctx context.Context, kind gocronometer.ExportKind, startDate time.Time, endDate time.Time) (string, error) {
+	var b strings.Builder
+	if err := m.export(ctx, &b, kind, startDate, endDate); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ExportStream returns the CSV data of the kind for the date range as a reader.
+func (m *MemoryClient) ExportStream(ctx context.Context, kind gocronometer.ExportKind, startDate time.Time, endDate time.Time) (io.ReadCloser, error) {
+	var b bytes.Buffer
+	if err := m.export(ctx, &b, kind, startDate, endDate); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&b), nil
+}
+
+// ExportServingsParsedWithLocation returns the servings for the date range parsed with the location provided.
+func (m *MemoryClient) ExportServingsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (gocronometer.ServingRecords, error) {
+	var b bytes.Buffer
+	if err := m.export(ctx, &b, gocronometer.ExportKindServings, startDate, endDate); err != nil {
+		return nil, err
+	}
+	return gocronometer.ParseServingsExport(&b, location)
+}
+
+// ExportExercisesParsedWithLocation returns the exercises for the date range parsed with the location provided.
+func (m *MemoryClient) ExportExercisesParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (gocronometer.ExerciseRecords, error) {
+	var b bytes.Buffer
+	if err := m.export(ctx, &b, gocronometer.ExportKindExercises, startDate, endDate); err != nil {
+		return nil, err
+	}
+	return gocronometer.ParseExerciseExport(&b, location)
+}
+
+// ExportBiometricRecordsParsedWithLocation returns the biometrics for the date range parsed with the location
+// provided.
+func (m *MemoryClient) ExportBiometricRecordsParsedWithLocation(ctx context.Context, startDate time.Time, endDate time.Time, location *time.Location) (gocronometer.BiometricRecords, error) {
+	var b bytes.Buffer
+	if err := m.export(ctx, &b, gocronometer.ExportKindBiometrics, startDate, endDate); err != nil {
+		return nil, err
+	}
+	return gocronometer.ParseBiometricRecordsExport(&b, location)
+}
+
+// export writes the CSV data of the kind for the date range to w. It fails as the client would when not logged in,
+// for an unknown kind or a cancelled context, and with the error set for the kind.
+func (m *MemoryClient) export(ctx context.Context, w io.Writer, kind gocronometer.ExportKind, startDate time.Time, endDate time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err, ok := m.exportErrs[kind]; ok {
+		return err
+	}
+	if !m.loggedIn {
+		return fmt.Errorf("%w: %s export was redirected to the login page", gocronometer.ErrSessionExpired, kind)
+	}
+
+	switch kind {
+	case gocronometer.ExportKindServings:
+		return writeServings(w, m.servings, startDate, endDate)
+	case gocronometer.ExportKindDailyNutrition:
+		return writeDailySummary(w, m.servings, startDate, endDate)
+	case gocronometer.ExportKindExercises:
+		return writeExercises(w, m.exercises, startDate, endDate)
+	case gocronometer.ExportKindBiometrics:
+		return writeBiometrics(w, m.biometrics, startDate, endDate)
+	case gocronometer.ExportKindNotes:
+		return writeNotes(w, m.notes, startDate, endDate)
+	default:
+		return &gocronometer.StatusError{Op: string(kind) + " export", StatusCode: http.StatusBadRequest, Body: "unknown export"}
+	}
+}
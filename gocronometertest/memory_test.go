@@ -0,0 +1,136 @@
+package gocronometertest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// exporterContract exercises the contract of gocronometer.Exporter against e, which must hold a serving, an exercise
+// and a biometric recorded at recorded.
+func exporterContract(t *testing.T, e gocronometer.Exporter, recorded time.Time) {
+	ctx := context.Background()
+
+	if _, err := e.Export(ctx, gocronometer.ExportKindServings, recorded, recorded); !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired before logging in but received %v", err)
+	}
+
+	if err := e.Login(ctx, gocronometertest.DefaultUsername, "wrong"); !errors.Is(err, gocronometer.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials but received %v", err)
+	}
+	if err := e.Login(ctx, gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	raw, err := e.Export(ctx, gocronometer.ExportKindNotes, recorded, recorded)
+	if err != nil || !strings.HasPrefix(raw, "Day,Group,Note") {
+		t.Fatalf("expected the notes CSV but found [%s]: %v", raw, err)
+	}
+
+	body, err := e.ExportStream(ctx, gocronometer.ExportKindServings, recorded, recorded)
+	if err != nil {
+		t.Fatalf("failed to stream servings: %s", err)
+	}
+	streamed, err := io.ReadAll(body)
+	_ = body.Close()
+	if err != nil || !strings.Contains(string(streamed), "Apple") {
+		t.Fatalf("expected the streamed servings to hold the seeded serving but found [%s]: %v", streamed, err)
+	}
+
+	servings, err := e.ExportServingsParsedWithLocation(ctx, recorded, recorded, time.UTC)
+	if err != nil || len(servings) != 1 || !servings[0].RecordedTime.Equal(recorded) {
+		t.Fatalf("expected the seeded serving but found %+v: %v", servings, err)
+	}
+	exercises, err := e.ExportExercisesParsedWithLocation(ctx, recorded, recorded, time.UTC)
+	if err != nil || len(exercises) != 1 || exercises[0].Exercise != "Walking" {
+		t.Fatalf("expected the seeded exercise but found %+v: %v", exercises, err)
+	}
+	biometrics, err := e.ExportBiometricRecordsParsedWithLocation(ctx, recorded, recorded, time.UTC)
+	if err != nil || len(biometrics) != 1 || biometrics[0].Amount != 72.5 {
+		t.Fatalf("expected the seeded biometric but found %+v: %v", biometrics, err)
+	}
+
+	// Records outside of the date range are not exported.
+	servings, err = e.ExportServingsParsedWithLocation(ctx, recorded.AddDate(0, 0, 1), recorded.AddDate(0, 0, 2), time.UTC)
+	if err != nil || len(servings) != 0 {
+		t.Fatalf("expected no servings outside of the date range but found %+v: %v", servings, err)
+	}
+
+	var statusErr *gocronometer.StatusError
+	if _, err = e.Export(ctx, gocronometer.ExportKind("unknown"), recorded, recorded); !errors.As(err, &statusErr) {
+		t.Fatalf("expected a StatusError for an unknown kind but received %v", err)
+	}
+
+	if err = e.Logout(ctx); err != nil {
+		t.Fatalf("failed to logout: %s", err)
+	}
+}
+
+// seed is implemented by both the Server and the MemoryClient.
+type seed interface {
+	AddServings(records ...gocronometer.ServingRecord)
+	AddExercises(records ...gocronometer.ExerciseRecord)
+	AddBiometrics(records ...gocronometer.BiometricRecord)
+	AddNotes(notes ...gocronometertest.Note)
+}
+
+func seedDiary(s seed, recorded time.Time) {
+	s.AddServings(gocronometer.ServingRecord{RecordedTime: recorded, FoodName: "Apple", QuantityValue: 1, QuantityUnits: "medium"})
+	s.AddExercises(gocronometer.ExerciseRecord{RecordedTime: recorded, Exercise: "Walking", Minutes: 30})
+	s.AddBiometrics(gocronometer.BiometricRecord{RecordedTime: recorded, Metric: "Weight", Unit: "kg", Amount: 72.5})
+	s.AddNotes(gocronometertest.Note{Day: recorded, Group: "Breakfast", Note: "Felt great"})
+}
+
+func TestExporterContract(t *testing.T) {
+	recorded := time.Date(2021, 6, 2, 13, 45, 0, 0, time.UTC)
+
+	t.Run("Client", func(t *testing.T) {
+		srv := gocronometertest.NewServer()
+		defer srv.Close()
+		seedDiary(srv, recorded)
+
+		exporterContract(t, srv.NewClient(), recorded)
+	})
+
+	t.Run("MemoryClient", func(t *testing.T) {
+		m := gocronometertest.NewMemoryClient()
+		seedDiary(m, recorded)
+
+		exporterContract(t, m, recorded)
+	})
+}
+
+func TestMemoryClient_Errors(t *testing.T) {
+	m := gocronometertest.NewMemoryClient()
+	ctx := context.Background()
+
+	loginErr := errors.New("maintenance")
+	m.SetLoginError(loginErr)
+	if err := m.Login(ctx, gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); !errors.Is(err, loginErr) {
+		t.Fatalf("expected the login error but received %v", err)
+	}
+	m.SetLoginError(nil)
+	if err := m.Login(ctx, gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	exportErr := errors.New("export failed")
+	m.SetExportError(gocronometer.ExportKindBiometrics, exportErr)
+	if _, err := m.ExportBiometricRecordsParsedWithLocation(ctx, time.Now(), time.Now(), time.UTC); !errors.Is(err, exportErr) {
+		t.Fatalf("expected the export error but received %v", err)
+	}
+	if _, err := m.Export(ctx, gocronometer.ExportKindServings, time.Now(), time.Now()); err != nil {
+		t.Fatalf("expected other kinds to be unaffected but received %v", err)
+	}
+
+	m.ExpireSession()
+	if _, err := m.Export(ctx, gocronometer.ExportKindServings, time.Now(), time.Now()); !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired after the session expired but received %v", err)
+	}
+}
@@ -0,0 +1,761 @@
+// Package gocronometertest provides an in-process fake of the Cronometer endpoints utilized by gocronometer. It allows
+// the client, and code built on top of it, to be exercised without a live Cronometer account.
+package gocronometertest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+)
+
+const (
+	// DefaultUsername is the username the server accepts unless changed with SetCredentials.
+	DefaultUsername = "user@example.com"
+
+	// DefaultPassword is the password the server accepts unless changed with SetCredentials.
+	DefaultPassword = "password"
+
+	// DefaultUserID is the user ID returned from GWT authentication unless changed with SetUserID.
+	DefaultUserID = 1234567
+)
+
+// The following are the GWT exception classes the server responds with.
+const (
+	// NotLoggedInException is returned by GWT calls made without a valid session.
+	NotLoggedInException = "com.cronometer.shared.rpc.NotLoggedInException/1617479163"
+
+	// IncompatibleRemoteServiceException is returned by GWT calls made with an unknown policy strong name.
+	IncompatibleRemoteServiceException = "com.google.gwt.user.client.rpc.IncompatibleRemoteServiceException/3936916533"
+)
+
+// Endpoint identifies one of the emulated endpoints. It is used to inject failures and inspect request counts.
+type Endpoint string
+
+const (
+	EndpointLoginPage        Endpoint = "login.page"
+	EndpointLogin            Endpoint = "login"
+	EndpointTwoFactorPage    Endpoint = "login.2fa.page"
+	EndpointTwoFactor        Endpoint = "login.2fa"
+	EndpointGWTAuthenticate  Endpoint = "gwt.authenticate"
+	EndpointGWTGenerateToken Endpoint = "gwt.generateAuthorizationToken"
+	EndpointGWTLogout        Endpoint = "gwt.logout"
+	EndpointExport           Endpoint = "export"
+)
+
+// Failure is a failure that can be injected into an endpoint.
+type Failure int
+
+const (
+	// FailInternalError responds with a 500 status code.
+	FailInternalError Failure = iota
+
+	// FailBadCredentials rejects a login as if the credentials were invalid.
+	FailBadCredentials
+
+	// FailMissingAntiCSRF serves a login page without the anticsrf input.
+	FailMissingAntiCSRF
+
+	// FailExpiredSession responds as if the session had expired. GWT calls respond with a NotLoggedInException and
+	// exports are redirected to the login page.
+	FailExpiredSession
+
+	// FailVersionMismatch responds to GWT calls with an IncompatibleRemoteServiceException.
+	FailVersionMismatch
+
+	// FailUnavailable responds with a 503 status code and a Retry-After header of 0 seconds.
+	FailUnavailable
+
+	// FailBotChallenge responds with a 403 status code and a Cloudflare challenge page.
+	FailBotChallenge
+
+	// FailCaptchaRequired serves a page that requires a captcha to be solved.
+	FailCaptchaRequired
+
+	// FailMaintenance responds with a 503 status code and a maintenance page.
+	FailMaintenance
+
+	// FailAccountLocked serves a page reporting the account is locked.
+	FailAccountLocked
+)
+
+// injectedFailure is a failure queued for an endpoint along with the remaining number of times it applies. A negative
+// count applies until the failures are cleared.
+type injectedFailure struct {
+	failure Failure
+	times   int
+}
+
+// Note represents a single note in the diary.
+type Note struct {
+	Day   time.Time
+	Group string
+	Note  string
+}
+
+// Server is a fake Cronometer server backed by in-memory diary data. The zero value is not usable, a new server
+// should be started with NewServer and closed with Close when no longer needed.
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	username    string
+	password    string
+	userID      int
+	policy      string
+	permutation string
+	tzOffset    int
+
+	antiCSRF map[string]bool
+	sessions map[string]bool
+
+	// twoFactorSecret is the TOTP secret of the account if two-factor authentication is enabled and pending are the
+	// logins waiting for their second factor.
+	twoFactorSecret string
+	pending         map[string]bool
+	tokens          map[string]time.Time
+
+	tokenRequest TokenRequest
+
+	servings   gocronometer.ServingRecords
+	exercises  gocronometer.ExerciseRecords
+	biometrics gocronometer.BiometricRecords
+	notes      []Note
+
+	failures map[Endpoint][]injectedFailure
+	requests map[Endpoint]int
+}
+
+// NewServer starts a new fake Cronometer server accepting the default credentials.
+func NewServer() *Server {
+	s := &Server{
+		username:    DefaultUsername,
+		password:    DefaultPassword,
+		userID:      DefaultUserID,
+		policy:      gocronometer.GWTHeader,
+		permutation: gocronometer.GWTPermutation,
+		antiCSRF:    make(map[string]bool),
+		sessions:    make(map[string]bool),
+		pending:     make(map[string]bool),
+		tokens:      make(map[string]time.Time),
+		failures:    make(map[Endpoint][]injectedFailure),
+		requests:    make(map[Endpoint]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login/", s.handleLoginPage)
+	mux.HandleFunc("POST /login", s.handleLogin)
+	mux.HandleFunc("GET /login/2fa", s.handleTwoFactorPage)
+	mux.HandleFunc("POST /login/2fa", s.handleTwoFactor)
+	mux.HandleFunc("POST /cronometer/app", s.handleGWT)
+	mux.HandleFunc("GET /cronometer/{script}", s.handleGWTScript)
+	mux.HandleFunc("GET /export", s.handleExport)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// ClientOptions returns client options that direct every request of a client to the server. Rate limiting is disabled
+// as the server is local.
+func (s *Server) ClientOptions() *gocronometer.ClientOptions {
+	return &gocronometer.ClientOptions{BaseURL: s.URL, DisableRateLimit: true}
+}
+
+// NewClient returns a new client directed at the server.
+func (s *Server) NewClient() *gocronometer.Client {
+	return gocronometer.NewClient(s.ClientOptions())
+}
+
+// SetCredentials sets the username and password the server accepts.
+func (s *Server) SetCredentials(username string, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.username = username
+	s.password = password
+}
+
+// NewSession starts a session as if the user had logged in with a browser, for example through Google or Apple, and
+// returns the value of its sesnonce cookie.
+func (s *Server) NewSession() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nonce := randomHex()
+	s.sessions[nonce] = true
+	return nonce
+}
+
+// SetTwoFactorSecret enables two-factor authentication with the base32 TOTP secret provided. Logins then require the
+// current TOTP code of the secret. An empty secret disables it.
+func (s *Server) SetTwoFactorSecret(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.twoFactorSecret = secret
+}
+
+// SetUserID sets the user ID returned from GWT authentication.
+func (s *Server) SetUserID(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userID = userID
+}
+
+// SetGWTHeader sets the policy strong name the server expects at the start of every GWT request. Requests with any
+// other value fail with an IncompatibleRemoteServiceException.
+func (s *Server) SetGWTHeader(header string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = header
+}
+
+// SetGWTPermutation sets the permutation strong name advertised by the GWT bootstrap scripts.
+func (s *Server) SetGWTPermutation(permutation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permutation = permutation
+}
+
+// TimezoneOffset returns the timezone offset provided by the most recent GWT authentication.
+func (s *Server) TimezoneOffset() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tzOffset
+}
+
+// AddServings seeds the diary with the servings provided.
+func (s *Server) AddServings(records ...gocronometer.ServingRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servings = append(s.servings, records...)
+}
+
+// AddExercises seeds the diary with the exercises provided.
+func (s *Server) AddExercises(records ...gocronometer.ExerciseRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exercises = append(s.exercises, records...)
+}
+
+// AddBiometrics seeds the diary with the biometrics provided.
+func (s *Server) AddBiometrics(records ...gocronometer.BiometricRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.biometrics = append(s.biometrics, records...)
+}
+
+// AddNotes seeds the diary with the notes provided.
+func (s *Server) AddNotes(notes ...Note) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notes = append(s.notes, notes...)
+}
+
+// InjectFailure causes the next times requests to the endpoint to fail with f. A times value less than 1 causes every
+// request to fail until ClearFailures is called. Failures injected for the same endpoint are applied in order.
+func (s *Server) InjectFailure(endpoint Endpoint, f Failure, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if times < 1 {
+		times = -1
+	}
+	s.failures[endpoint] = append(s.failures[endpoint], injectedFailure{failure: f, times: times})
+}
+
+// ClearFailures removes all injected failures.
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[Endpoint][]injectedFailure)
+}
+
+// ExpireSessions invalidates every session and export token issued so far.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+	s.tokens = make(map[string]time.Time)
+}
+
+// ExpireTokens invalidates every export token issued so far while keeping the sessions alive.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
+// TokenRequest is the lifetime and scope requested when generating an export token.
+type TokenRequest struct {
+	Lifetime time.Duration
+	Scope    gocronometer.AuthScope
+}
+
+// LastTokenRequest returns the lifetime and scope requested by the most recent export token generation.
+func (s *Server) LastTokenRequest() TokenRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequest
+}
+
+// RequestCount returns the number of requests the endpoint has received.
+func (s *Server) RequestCount(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// begin records a request to the endpoint and returns the failure to apply to it, if any. The server lock must be held.
+func (s *Server) begin(endpoint Endpoint) (Failure, bool) {
+	s.requests[endpoint]++
+
+	queue := s.failures[endpoint]
+	if len(queue) == 0 {
+		return 0, false
+	}
+
+	f := queue[0].failure
+	if queue[0].times > 0 {
+		queue[0].times--
+		if queue[0].times == 0 {
+			queue = queue[1:]
+		}
+	}
+	s.failures[endpoint] = queue
+
+	return f, true
+}
+
+// validSession reports if the request carries the cookie of a live session. The server lock must be held.
+func (s *Server) validSession(r *http.Request) bool {
+	cookie, err := r.Cookie("sesnonce")
+	if err != nil {
+		return false
+	}
+	return s.sessions[cookie.Value]
+}
+
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, failed := s.begin(EndpointLoginPage)
+	if failed && f != FailMissingAntiCSRF {
+		writeFailure(w, f)
+		return
+	}
+
+	input := ""
+	if !failed {
+		csrf := randomHex()
+		s.antiCSRF[csrf] = true
+		input = fmt.Sprintf(`<input type="hidden" name="anticsrf" value="%s">`, csrf)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Cronometer Login</title></head>
+<body><form action="/login" method="POST">%s
+<input type="email" name="username"><input type="password" name="password">
+</form></body></html>`, input)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, failed := s.begin(EndpointLogin)
+	if failed && f != FailBadCredentials {
+		writeFailure(w, f)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp gocronometer.LoginResponse
+	switch {
+	case !s.antiCSRF[r.PostForm.Get("anticsrf")]:
+		resp.Error = "Your session has expired. Please refresh the page and try again."
+	case failed, r.PostForm.Get("username") != s.username, r.PostForm.Get("password") != s.password:
+		resp.Error = "Invalid email or password."
+	case s.twoFactorSecret != "":
+		delete(s.antiCSRF, r.PostForm.Get("anticsrf"))
+		pending := randomHex()
+		s.pending[pending] = true
+		http.SetCookie(w, &http.Cookie{Name: "pending2fa", Value: pending, Path: "/", HttpOnly: true})
+		resp.Redirect = "/login/2fa"
+	default:
+		delete(s.antiCSRF, r.PostForm.Get("anticsrf"))
+		s.startSession(w, &resp)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// startSession sets the cookie of a new session and marks the login response successful. The server lock must be
+// held.
+func (s *Server) startSession(w http.ResponseWriter, resp *gocronometer.LoginResponse) {
+	nonce := randomHex()
+	s.sessions[nonce] = true
+	http.SetCookie(w, &http.Cookie{Name: "sesnonce", Value: nonce, Path: "/", HttpOnly: true})
+	resp.Success = true
+	resp.Redirect = "/#diary"
+}
+
+func (s *Server) handleTwoFactorPage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, failed := s.begin(EndpointTwoFactorPage); failed {
+		writeFailure(w, f)
+		return
+	}
+
+	csrf := randomHex()
+	s.antiCSRF[csrf] = true
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Cronometer Two-Factor Authentication</title></head>
+<body><form action="/login/2fa" method="POST"><input type="hidden" name="anticsrf" value="%s">
+<input type="text" name="code" autocomplete="one-time-code">
+</form></body></html>`, csrf)
+}
+
+func (s *Server) handleTwoFactor(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, failed := s.begin(EndpointTwoFactor)
+	if failed {
+		writeFailure(w, f)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pending, _ := r.Cookie("pending2fa")
+
+	var resp gocronometer.LoginResponse
+	switch {
+	case !s.antiCSRF[r.PostForm.Get("anticsrf")], pending == nil || !s.pending[pending.Value]:
+		resp.Error = "Your session has expired. Please refresh the page and try again."
+	case !s.validTOTP(r.PostForm.Get("code")):
+		resp.Error = "Invalid verification code."
+	default:
+		delete(s.antiCSRF, r.PostForm.Get("anticsrf"))
+		delete(s.pending, pending.Value)
+		s.startSession(w, &resp)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// validTOTP reports if code is the TOTP code of the two-factor secret for the current, previous or next period. The
+// server lock must be held.
+func (s *Server) validTOTP(code string) bool {
+	now := time.Now()
+	for _, t := range []time.Time{now, now.Add(-30 * time.Second), now.Add(30 * time.Second)} {
+		if expected, err := gocronometer.GenerateTOTP(s.twoFactorSecret, t); err == nil && code == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// gwtCall is a parsed GWT request body.
+type gwtCall struct {
+	strings []string
+	payload []string
+}
+
+// str resolves the string table reference v. References are 1 based with 0 being null.
+func (c gwtCall) str(v string) string {
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 1 || i > len(c.strings) {
+		return ""
+	}
+	return c.strings[i-1]
+}
+
+// moduleBase returns the module base of the call.
+func (c gwtCall) moduleBase() string {
+	if len(c.payload) < 1 {
+		return ""
+	}
+	return c.str(c.payload[0])
+}
+
+// header returns the policy strong name of the call.
+func (c gwtCall) header() string {
+	if len(c.payload) < 2 {
+		return ""
+	}
+	return c.str(c.payload[1])
+}
+
+// method returns the method name of the call.
+func (c gwtCall) method() string {
+	if len(c.payload) < 4 {
+		return ""
+	}
+	return c.str(c.payload[3])
+}
+
+// params returns the values following the parameter type signatures.
+func (c gwtCall) params() []string {
+	if len(c.payload) < 5 {
+		return nil
+	}
+	n, err := strconv.Atoi(c.payload[4])
+	if err != nil || len(c.payload) < 5+n {
+		return nil
+	}
+	return c.payload[5+n:]
+}
+
+// parseGWTCall parses a pipe delimited GWT request body.
+func parseGWTCall(body string) (gwtCall, error) {
+	fields := strings.Split(strings.TrimSuffix(body, "|"), "|")
+	if len(fields) < 3 {
+		return gwtCall{}, fmt.Errorf("malformed request")
+	}
+
+	n, err := strconv.Atoi(fields[2])
+	if err != nil || len(fields) < 3+n {
+		return gwtCall{}, fmt.Errorf("malformed string table")
+	}
+
+	strs := fields[3 : 3+n]
+	for i, v := range strs {
+		strs[i] = unescapeGWTString(v)
+	}
+
+	return gwtCall{strings: strs, payload: fields[3+n:]}, nil
+}
+
+// unescapeGWTString reverses the escaping GWT applies to strings in a request body.
+func unescapeGWTString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case '!':
+			b.WriteByte('|')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// writeFailure writes the generic response of a failure that has no endpoint specific behavior.
+func writeFailure(w http.ResponseWriter, f Failure) {
+	switch f {
+	case FailUnavailable:
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	case FailBotChallenge:
+		w.Header().Set("cf-mitigated", "challenge")
+		writePage(w, http.StatusForbidden, "Just a moment...",
+			`<div id="challenge-body-text">Checking your browser before accessing cronometer.com.</div>
+<script src="/cdn-cgi/challenge-platform/h/g/orchestrate/chl_page/v1"></script>
+<div>Ray ID: 8f1c2a3b4c5d6e7f &bull; Your IP: 203.0.113.7</div>`)
+	case FailCaptchaRequired:
+		writePage(w, http.StatusOK, "Cronometer Login",
+			`<form action="/login" method="POST"><div class="g-recaptcha" data-sitekey="test"></div></form>`)
+	case FailMaintenance:
+		writePage(w, http.StatusServiceUnavailable, "Cronometer Maintenance",
+			`<h1>Cronometer is down for scheduled maintenance.</h1><p>We'll be right back.</p>`)
+	case FailAccountLocked:
+		writePage(w, http.StatusOK, "Cronometer Login",
+			`<p>Your account has been temporarily locked after too many failed login attempts.</p>`)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// writePage writes an HTML page with the status code, title and body provided.
+func writePage(w http.ResponseWriter, statusCode int, title string, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><title>%s</title></head>\n<body>%s</body></html>", title, body)
+}
+
+// writeGWTException writes a GWT exception response of the class provided.
+func writeGWTException(w http.ResponseWriter, class string, message string) {
+	b, _ := json.Marshal([]string{class, message})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "//EX[2,1,%s,0,7]", b)
+}
+
+func (s *Server) handleGWT(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	call, err := parseGWTCall(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var endpoint Endpoint
+	switch call.method() {
+	case "authenticate":
+		endpoint = EndpointGWTAuthenticate
+	case "generateAuthorizationToken":
+		endpoint = EndpointGWTGenerateToken
+	case "logout":
+		endpoint = EndpointGWTLogout
+	default:
+		http.Error(w, "unknown method", http.StatusBadRequest)
+		return
+	}
+
+	f, failed := s.begin(endpoint)
+	switch {
+	case failed && f == FailExpiredSession:
+		writeGWTException(w, NotLoggedInException, "Not logged in")
+		return
+	case failed && f == FailVersionMismatch, call.header() != s.policy, call.moduleBase() != s.URL+"/cronometer/":
+		writeGWTException(w, IncompatibleRemoteServiceException, "This application is out of date, please click the refresh button on your browser.")
+		return
+	case failed:
+		writeFailure(w, f)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	switch endpoint {
+	case EndpointGWTAuthenticate:
+		if !s.validSession(r) {
+			writeGWTException(w, NotLoggedInException, "Not logged in")
+			return
+		}
+		if params := call.params(); len(params) > 1 {
+			s.tzOffset, _ = strconv.Atoi(params[1])
+		}
+		fmt.Fprintf(w, "//OK[%d,[],0,7]", s.userID)
+
+	case EndpointGWTGenerateToken:
+		params := call.params()
+		if len(params) < 5 || !s.sessions[call.str(params[0])] || params[1] != strconv.Itoa(s.userID) {
+			writeGWTException(w, NotLoggedInException, "Not logged in")
+			return
+		}
+		lifetime, err := strconv.Atoi(params[2])
+		if err != nil {
+			http.Error(w, "malformed lifetime", http.StatusBadRequest)
+			return
+		}
+		scope, err := strconv.Atoi(params[4])
+		if err != nil {
+			http.Error(w, "malformed scope", http.StatusBadRequest)
+			return
+		}
+		s.tokenRequest = TokenRequest{Lifetime: time.Duration(lifetime) * time.Second, Scope: gocronometer.AuthScope(scope)}
+		token := randomHex()
+		s.tokens[token] = time.Now().Add(s.tokenRequest.Lifetime)
+		fmt.Fprintf(w, `//OK[1,["%s"],0,7]`, token)
+
+	case EndpointGWTLogout:
+		if params := call.params(); len(params) > 0 {
+			delete(s.sessions, call.str(params[0]))
+		}
+		fmt.Fprint(w, "//OK[[],0,7]")
+	}
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, failed := s.begin(EndpointExport)
+	switch {
+	case failed && f == FailExpiredSession:
+		http.Redirect(w, r, "/login/", http.StatusFound)
+		return
+	case failed:
+		writeFailure(w, f)
+		return
+	}
+
+	q := r.URL.Query()
+	if expires, ok := s.tokens[q.Get("nonce")]; !ok || time.Now().After(expires) {
+		http.Error(w, "invalid nonce", http.StatusForbidden)
+		return
+	}
+
+	start, err := time.Parse("2006-01-02", q.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", q.Get("end"))
+	if err != nil {
+		http.Error(w, "invalid end", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	switch q.Get("generate") {
+	case "servings":
+		err = writeServings(w, s.servings, start, end)
+	case "dailySummary":
+		err = writeDailySummary(w, s.servings, start, end)
+	case "exercises":
+		err = writeExercises(w, s.exercises, start, end)
+	case "biometrics":
+		err = writeBiometrics(w, s.biometrics, start, end)
+	case "notes":
+		err = writeNotes(w, s.notes, start, end)
+	default:
+		http.Error(w, "unknown export", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// randomHex returns a random 32 character hex string in the shape of the nonces and tokens Cronometer issues.
+func randomHex() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+package gocronometertest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestServer_ExportServingsParsed(t *testing.T) {
+	srv := gocronometertest.NewServer()
+	defer srv.Close()
+
+	recorded := time.Date(2021, 6, 2, 13, 45, 0, 0, time.UTC)
+	srv.AddServings(gocronometer.ServingRecord{RecordedTime: recorded, Group: "Lunch", FoodName: "Apple",
+		QuantityValue: 1.5, QuantityUnits: "medium", EnergyKcal: 142.5, Category: "Fruits"})
+
+	client := srv.NewClient()
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	servings, err := client.ExportServingsParsed(context.Background(), recorded, recorded)
+	if err != nil {
+		t.Fatalf("failed to export servings: %s", err)
+	}
+
+	if len(servings) != 1 {
+		t.Fatalf("expected 1 serving but found %d", len(servings))
+	}
+
+	s := servings[0]
+	if !s.RecordedTime.Equal(recorded) || s.FoodName != "Apple" || s.QuantityValue != 1.5 || s.EnergyKcal != 142.5 {
+		t.Fatalf("serving did not match the seeded serving: %+v", s)
+	}
+}
+
+func TestServer_InjectFailure(t *testing.T) {
+	srv := gocronometertest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+
+	srv.InjectFailure(gocronometertest.EndpointLogin, gocronometertest.FailBadCredentials, 1)
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err == nil {
+		t.Fatalf("logged in while bad credentials were injected")
+	}
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login after the injected failure was consumed: %s", err)
+	}
+
+	srv.InjectFailure(gocronometertest.EndpointGWTGenerateToken, gocronometertest.FailVersionMismatch, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err == nil {
+			t.Fatalf("exported notes while a version mismatch was injected")
+		}
+	}
+
+	srv.ClearFailures()
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export notes after failures were cleared: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 3 {
+		t.Fatalf("expected 3 token requests but found %d", n)
+	}
+}
+
+func TestServer_ExpireSessions(t *testing.T) {
+	srv := gocronometertest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	srv.ExpireSessions()
+
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err == nil {
+		t.Fatalf("exported notes with an expired session")
+	}
+}
@@ -0,0 +1,301 @@
+package gocronometer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GWTRPCVersion is the version of the GWT-RPC serialization stream written by GWTCall.
+const GWTRPCVersion = 7
+
+// The following are the type signatures of common GWT parameter and object types. Primitive types are identified by
+// their JNI signature and objects by their class name followed by the CRC of their serialization policy.
+const (
+	GWTTypeBoolean   = "Z"
+	GWTTypeByte      = "B"
+	GWTTypeChar      = "C"
+	GWTTypeDouble    = "D"
+	GWTTypeFloat     = "F"
+	GWTTypeInt       = "I"
+	GWTTypeLong      = "J"
+	GWTTypeShort     = "S"
+	GWTTypeString    = "java.lang.String/2004016611"
+	GWTTypeInteger   = "java.lang.Integer/3438268394"
+	GWTTypeArrayList = "java.util.ArrayList/4159755760"
+)
+
+// GWTCall is a GWT-RPC call of a method of a service. It is encoded into the body of a GWT request with Encode.
+type GWTCall struct {
+	// Service is the fully qualified name of the service interface such as
+	// "com.cronometer.shared.rpc.CronometerService".
+	Service string
+
+	// Method is the name of the method called.
+	Method string
+
+	// Params are the parameters of the method in order.
+	Params []GWTParam
+}
+
+// GWTParam is a parameter of a GWTCall.
+type GWTParam struct {
+	// Type is the type signature of the parameter as declared by the method, such as GWTTypeInt or GWTTypeString.
+	Type string
+
+	// Value is the value of the parameter. It must match the declared type. Parameters declared as a primitive type
+	// or String take a GWTInt, GWTLong, GWTDouble, GWTBool or GWTString while those declared as any other object
+	// type take a GWTObject, GWTList or GWTNull.
+	Value GWTValue
+}
+
+// GWTValue is a value that can be written to a GWT-RPC serialization stream.
+type GWTValue interface {
+	writeGWT(w *gwtWriter)
+}
+
+// GWTString is a string. A nil string is written with GWTNull.
+type GWTString string
+
+// GWTInt is an int, short, byte or char.
+type GWTInt int32
+
+// GWTLong is a long.
+type GWTLong int64
+
+// GWTDouble is a double or float.
+type GWTDouble float64
+
+// GWTBool is a boolean.
+type GWTBool bool
+
+// gwtNull is the type of GWTNull.
+type gwtNull struct{}
+
+// GWTNull is a null string or object.
+var GWTNull GWTValue = gwtNull{}
+
+// GWTObject is an instance of a serializable class. The fields are written in the order the serializer of the class
+// reads them, which for generated serializers is the declared fields in alphabetical order starting with the fields of
+// the superclass. Fields declared as String take a GWTString and fields of other object types a GWTObject. Writing the
+// same *GWTObject again writes a reference to the first instance.
+type GWTObject struct {
+	// Type is the type signature of the class.
+	Type string
+
+	// Fields are the values of the serialized fields of the instance.
+	Fields []GWTValue
+}
+
+// GWTList is a java.util.List of objects. Elements of a list of strings are written with GWTBoxedString.
+type GWTList struct {
+	// Type is the type signature of the list implementation. It defaults to GWTTypeArrayList.
+	Type string
+
+	// Elements are the elements of the list.
+	Elements []GWTValue
+}
+
+// GWTInteger returns the java.lang.Integer object of v.
+func GWTInteger(v int32) *GWTObject {
+	return &GWTObject{Type: GWTTypeInteger, Fields: []GWTValue{GWTInt(v)}}
+}
+
+// GWTBoxedString returns the java.lang.String object of s, as written when a string is an element of a collection or
+// a field of type Object.
+func GWTBoxedString(s string) *GWTObject {
+	return &GWTObject{Type: GWTTypeString, Fields: []GWTValue{GWTString(s)}}
+}
+
+// GWTEnum returns the constant of the enum of type signature typ with the ordinal provided.
+func GWTEnum(typ string, ordinal int32) *GWTObject {
+	return &GWTObject{Type: typ, Fields: []GWTValue{GWTInt(ordinal)}}
+}
+
+// Encode encodes the call into the body of a GWT request for the module base and the strong name of the serialization
+// policy provided.
+func (call GWTCall) Encode(moduleBase string, strongName string) string {
+	w := &gwtWriter{stringIndex: make(map[string]int), objectIndex: make(map[*GWTObject]int)}
+
+	w.writeString(moduleBase)
+	w.writeString(strongName)
+	w.writeString(call.Service)
+	w.writeString(call.Method)
+	w.writeInt(int32(len(call.Params)))
+	for _, param := range call.Params {
+		w.writeString(param.Type)
+	}
+	for _, param := range call.Params {
+		param.Value.writeGWT(w)
+	}
+
+	return w.String()
+}
+
+// gwtWriter builds the string table and payload of a GWT-RPC serialization stream.
+type gwtWriter struct {
+	strings     []string
+	stringIndex map[string]int
+	payload     []string
+	objects     int
+	objectIndex map[*GWTObject]int
+}
+
+// append appends the token to the payload.
+func (w *gwtWriter) append(token string) {
+	w.payload = append(w.payload, token)
+}
+
+// writeString writes the index of s in the string table, adding it if it is not there yet.
+func (w *gwtWriter) writeString(s string) {
+	i, ok := w.stringIndex[s]
+	if !ok {
+		w.strings = append(w.strings, s)
+		i = len(w.strings)
+		w.stringIndex[s] = i
+	}
+	w.append(strconv.Itoa(i))
+}
+
+// writeInt writes an int.
+func (w *gwtWriter) writeInt(v int32) {
+	w.append(strconv.FormatInt(int64(v), 10))
+}
+
+// writeObjectHeader writes the type signature of a new object, counting it so that later references to it can be
+// written.
+func (w *gwtWriter) writeObjectHeader(typ string) {
+	w.objects++
+	w.writeString(typ)
+}
+
+// String returns the serialization stream.
+func (w *gwtWriter) String() string {
+	b := strings.Builder{}
+	b.WriteString(strconv.Itoa(GWTRPCVersion))
+	b.WriteString("|0|")
+	b.WriteString(strconv.Itoa(len(w.strings)))
+	b.WriteByte('|')
+	for _, s := range w.strings {
+		b.WriteString(escapeGWTString(s))
+		b.WriteByte('|')
+	}
+	for _, token := range w.payload {
+		b.WriteString(token)
+		b.WriteByte('|')
+	}
+	return b.String()
+}
+
+func (v GWTString) writeGWT(w *gwtWriter) {
+	w.writeString(string(v))
+}
+
+func (v GWTInt) writeGWT(w *gwtWriter) {
+	w.writeInt(int32(v))
+}
+
+func (v GWTLong) writeGWT(w *gwtWriter) {
+	w.append(gwtLongBase64(int64(v)))
+}
+
+func (v GWTDouble) writeGWT(w *gwtWriter) {
+	// Infinities are written as Java's Double.parseDouble reads them rather than as +Inf and -Inf.
+	switch {
+	case math.IsInf(float64(v), 1):
+		w.append("Infinity")
+	case math.IsInf(float64(v), -1):
+		w.append("-Infinity")
+	default:
+		w.append(strconv.FormatFloat(float64(v), 'g', -1, 64))
+	}
+}
+
+func (v GWTBool) writeGWT(w *gwtWriter) {
+	if v {
+		w.append("1")
+	} else {
+		w.append("0")
+	}
+}
+
+func (gwtNull) writeGWT(w *gwtWriter) {
+	w.append("0")
+}
+
+func (v *GWTObject) writeGWT(w *gwtWriter) {
+	if v == nil {
+		w.append("0")
+		return
+	}
+
+	// Objects already written are referenced by the negated position they were written at.
+	if i, ok := w.objectIndex[v]; ok {
+		w.writeInt(int32(-i))
+		return
+	}
+
+	w.writeObjectHeader(v.Type)
+	w.objectIndex[v] = w.objects
+	for _, field := range v.Fields {
+		field.writeGWT(w)
+	}
+}
+
+func (v *GWTList) writeGWT(w *gwtWriter) {
+	if v == nil {
+		w.append("0")
+		return
+	}
+
+	typ := v.Type
+	if typ == "" {
+		typ = GWTTypeArrayList
+	}
+
+	w.writeObjectHeader(typ)
+	w.writeInt(int32(len(v.Elements)))
+	for _, element := range v.Elements {
+		element.writeGWT(w)
+	}
+}
+
+// escapeGWTString escapes the backslashes, pipes and null characters of s as GWT does when writing the string table.
+func escapeGWTString(s string) string {
+	if !strings.ContainsAny(s, "\\|\x00") {
+		return s
+	}
+
+	b := strings.Builder{}
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '|':
+			b.WriteString(`\!`)
+		case 0:
+			b.WriteString(`\0`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// gwtLongBase64Chars are the digits of the base 64 encoding of longs.
+const gwtLongBase64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789$_"
+
+// gwtLongBase64 encodes v as GWT writes longs, the 64 bits of v in base 64 starting with the top 4 bits and omitting
+// leading zero digits.
+func gwtLongBase64(v int64) string {
+	u := uint64(v)
+	b := make([]byte, 0, 11)
+	for shift := 60; shift >= 0; shift -= 6 {
+		digit := (u >> uint(shift)) & 0x3f
+		if len(b) == 0 && digit == 0 && shift != 0 {
+			continue
+		}
+		b = append(b, gwtLongBase64Chars[digit])
+	}
+	return string(b)
+}
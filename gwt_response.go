@@ -0,0 +1,426 @@
+package gocronometer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// GWTReader reads the values of a successful GWT-RPC response in the order the service wrote them. It is created with
+// DecodeGWTResponse.
+type GWTReader struct {
+	// Version is the version of the serialization stream.
+	Version int
+
+	// Flags are the flags of the serialization stream.
+	Flags int
+
+	// Strings is the string table of the response.
+	Strings []string
+
+	values  []any
+	pos     int
+	objects []string
+}
+
+// DecodeGWTResponse decodes the body of a GWT-RPC response. A //OK response returns a reader of its values. A //EX
+// response returns a GWTError carrying the class and message of the exception thrown by the service. Any other body,
+// or one that cannot be decoded, returns an error matching ErrUnexpectedResponse.
+func DecodeGWTResponse(body string) (*GWTReader, error) {
+	switch {
+	case strings.HasPrefix(body, "//OK"):
+		return parseGWTResponse(body[len("//OK"):])
+	case strings.HasPrefix(body, "//EX"):
+		gwtErr := &GWTError{Body: body}
+		if r, err := parseGWTResponse(body[len("//EX"):]); err == nil {
+			gwtErr.Exception, gwtErr.Message = r.readException()
+		}
+		return nil, gwtErr
+	default:
+		return nil, fmt.Errorf("%w: response was not a GWT response", ErrUnexpectedResponse)
+	}
+}
+
+// decodeGWTResponse decodes the response of the GWT method provided. Errors carry the body with the secrets of the
+// client redacted.
+func (c *Client) decodeGWTResponse(method string, body []byte) (*GWTReader, error) {
+	r, err := DecodeGWTResponse(string(body))
+	if err == nil {
+		return r, nil
+	}
+
+	var gwtErr *GWTError
+	if errors.As(err, &gwtErr) {
+		gwtErr.Method = method
+		gwtErr.Body = c.Redact(gwtErr.Body)
+		return nil, c.checkGWTValues(gwtErr)
+	}
+
+	return nil, fmt.Errorf("gwt %s: %w: %s", method, err, c.Redact(string(body)))
+}
+
+// parseGWTResponse parses the payload of a response following its //OK or //EX prefix. The payload is an array of
+// the values in reverse order followed by the string table, the flags and the version.
+func parseGWTResponse(payload string) (*GWTReader, error) {
+	p := &gwtParser{s: strings.TrimSpace(payload)}
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.i != len(p.s) {
+		return nil, fmt.Errorf("%w: unexpected data after GWT response at offset %d", ErrUnexpectedResponse, p.i)
+	}
+
+	elements, ok := v.([]any)
+	if !ok || len(elements) < 3 {
+		return nil, fmt.Errorf("%w: GWT response is not an array of values, strings, flags and version", ErrUnexpectedResponse)
+	}
+
+	version, versionOK := elements[len(elements)-1].(float64)
+	flags, flagsOK := elements[len(elements)-2].(float64)
+	table, tableOK := elements[len(elements)-3].([]any)
+	if !versionOK || !flagsOK || !tableOK {
+		return nil, fmt.Errorf("%w: GWT response is missing its string table, flags or version", ErrUnexpectedResponse)
+	}
+
+	r := &GWTReader{Version: int(version), Flags: int(flags), Strings: make([]string, len(table))}
+	for i, s := range table {
+		if r.Strings[i], ok = s.(string); !ok {
+			return nil, fmt.Errorf("%w: GWT string table entry %d is not a string", ErrUnexpectedResponse, i+1)
+		}
+	}
+
+	values := elements[:len(elements)-3]
+	r.values = make([]any, len(values))
+	for i, value := range values {
+		r.values[len(values)-1-i] = value
+	}
+
+	return r, nil
+}
+
+// Remaining returns the number of values that have not been read.
+func (r *GWTReader) Remaining() int {
+	return len(r.values) - r.pos
+}
+
+// End returns an error matching ErrUnexpectedResponse if any value has not been read. It is called once the values
+// expected in a response have been read so a change in the layout of the response fails rather than being misread.
+func (r *GWTReader) End() error {
+	if n := r.Remaining(); n != 0 {
+		return fmt.Errorf("%w: %d GWT values left unread", ErrUnexpectedResponse, n)
+	}
+	return nil
+}
+
+// next returns the next value.
+func (r *GWTReader) next(kind string) (any, error) {
+	if r.pos >= len(r.values) {
+		return nil, fmt.Errorf("%w: no GWT value left to read as %s", ErrUnexpectedResponse, kind)
+	}
+	v := r.values[r.pos]
+	r.pos++
+	return v, nil
+}
+
+// nextNumber returns the next value, which must be a number.
+func (r *GWTReader) nextNumber(kind string) (float64, error) {
+	v, err := r.next(kind)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%w: GWT value %d is %v rather than a %s", ErrUnexpectedResponse, r.pos, v, kind)
+	}
+	return n, nil
+}
+
+// ReadInt reads an int, short, byte or char.
+func (r *GWTReader) ReadInt() (int32, error) {
+	n, err := r.nextNumber("int")
+	if err != nil {
+		return 0, err
+	}
+	if n != math.Trunc(n) || n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: GWT value %d is %v rather than an int", ErrUnexpectedResponse, r.pos, n)
+	}
+	return int32(n), nil
+}
+
+// ReadLong reads a long. Longs are written as base 64 strings, or as numbers by older versions of GWT.
+func (r *GWTReader) ReadLong() (int64, error) {
+	v, err := r.next("long")
+	if err != nil {
+		return 0, err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		// 2^63, the float64 closest to math.MaxInt64, is out of range.
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: GWT value %d is %v rather than a long", ErrUnexpectedResponse, r.pos, v)
+		}
+		return int64(v), nil
+	case string:
+		var u uint64
+		for _, c := range v {
+			digit := strings.IndexRune(gwtLongBase64Chars, c)
+			if digit < 0 {
+				return 0, fmt.Errorf("%w: GWT value %d is %q rather than a long", ErrUnexpectedResponse, r.pos, v)
+			}
+			u = u<<6 | uint64(digit)
+		}
+		return int64(u), nil
+	default:
+		return 0, fmt.Errorf("%w: GWT value %d is %v rather than a long", ErrUnexpectedResponse, r.pos, v)
+	}
+}
+
+// ReadDouble reads a double or float.
+func (r *GWTReader) ReadDouble() (float64, error) {
+	return r.nextNumber("double")
+}
+
+// ReadBool reads a boolean.
+func (r *GWTReader) ReadBool() (bool, error) {
+	n, err := r.nextNumber("boolean")
+	return n != 0, err
+}
+
+// ReadString reads a string. A null string is read as an empty string.
+func (r *GWTReader) ReadString() (string, error) {
+	i, err := r.ReadInt()
+	if err != nil {
+		return "", err
+	}
+	if i == 0 {
+		return "", nil
+	}
+	if i < 0 || int(i) > len(r.Strings) {
+		return "", fmt.Errorf("%w: GWT string reference %d is outside the string table of %d strings",
+			ErrUnexpectedResponse, i, len(r.Strings))
+	}
+	return r.Strings[i-1], nil
+}
+
+// ReadObjectType reads the start of an object and returns its type signature, after which the fields of the object
+// follow. An empty type signature is returned for a null object. If the object is a reference to an object read
+// earlier, ref is its 1-based position among the objects read, typ is its type signature and no fields follow.
+func (r *GWTReader) ReadObjectType() (typ string, ref int, err error) {
+	i, err := r.ReadInt()
+	if err != nil {
+		return "", 0, err
+	}
+
+	switch {
+	case i == 0:
+		return "", 0, nil
+	case i < 0:
+		ref = int(-i)
+		if ref > len(r.objects) {
+			return "", 0, fmt.Errorf("%w: GWT object reference %d is to an object not read yet", ErrUnexpectedResponse, ref)
+		}
+		return r.objects[ref-1], ref, nil
+	case int(i) > len(r.Strings):
+		return "", 0, fmt.Errorf("%w: GWT type reference %d is outside the string table of %d strings",
+			ErrUnexpectedResponse, i, len(r.Strings))
+	default:
+		typ = r.Strings[i-1]
+		r.objects = append(r.objects, typ)
+		return typ, 0, nil
+	}
+}
+
+// readException reads the exception of a //EX response and returns its class, without the serialization policy CRC
+// of its type signature, and its message.
+func (r *GWTReader) readException() (class string, message string) {
+	typ, _, err := r.ReadObjectType()
+	if err != nil {
+		return "", ""
+	}
+	class, _, _ = strings.Cut(typ, "/")
+
+	// The message of the Throwable is the first field of every exception.
+	message, _ = r.ReadString()
+	return class, message
+}
+
+// gwtParser parses the JavaScript array literal of a GWT response. Arrays too large for a single literal are joined
+// with concat by GWT, so "[1,2].concat([3])" is parsed as [1,2,3].
+type gwtParser struct {
+	s string
+	i int
+}
+
+// skipSpace skips whitespace.
+func (p *gwtParser) skipSpace() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// errorf returns an error matching ErrUnexpectedResponse at the current offset.
+func (p *gwtParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: malformed GWT response at offset %d: %s", ErrUnexpectedResponse, p.i, fmt.Sprintf(format, args...))
+}
+
+// parseValue parses a number, string or array.
+func (p *gwtParser) parseValue() (any, error) {
+	p.skipSpace()
+	if p.i >= len(p.s) {
+		return nil, p.errorf("unexpected end")
+	}
+
+	switch c := p.s[p.i]; {
+	case c == '[':
+		return p.parseArray()
+	case c == '"' || c == '\'':
+		return p.parseString()
+	default:
+		return p.parseNumber()
+	}
+}
+
+// parseArray parses an array along with any arrays concatenated to it.
+func (p *gwtParser) parseArray() ([]any, error) {
+	p.i++ // [
+	elements := make([]any, 0)
+
+	for {
+		p.skipSpace()
+		if p.i < len(p.s) && p.s[p.i] == ']' && len(elements) == 0 {
+			p.i++
+			break
+		}
+
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, v)
+
+		p.skipSpace()
+		if p.i >= len(p.s) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.s[p.i] == ']' {
+			p.i++
+			break
+		}
+		if p.s[p.i] != ',' {
+			return nil, p.errorf("expected , or ] but found %q", p.s[p.i])
+		}
+		p.i++
+	}
+
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.i:], ".concat(") {
+		p.i += len(".concat(")
+		p.skipSpace()
+		if p.i >= len(p.s) || p.s[p.i] != '[' {
+			return nil, p.errorf("expected an array to concat")
+		}
+		rest, err := p.parseArray()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.i >= len(p.s) || p.s[p.i] != ')' {
+			return nil, p.errorf("unterminated concat")
+		}
+		p.i++
+		elements = append(elements, rest...)
+	}
+
+	return elements, nil
+}
+
+// parseString parses a JavaScript string literal in single or double quotes.
+func (p *gwtParser) parseString() (string, error) {
+	quote := p.s[p.i]
+	p.i++
+
+	b := strings.Builder{}
+	var units []uint16
+	flush := func() {
+		if len(units) > 0 {
+			b.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		switch {
+		case c == quote:
+			p.i++
+			flush()
+			return b.String(), nil
+		case c != '\\':
+			flush()
+			b.WriteByte(c)
+			p.i++
+			continue
+		}
+
+		if p.i+1 >= len(p.s) {
+			break
+		}
+		esc := p.s[p.i+1]
+		p.i += 2
+
+		switch esc {
+		case 'u', 'x':
+			n := 4
+			if esc == 'x' {
+				n = 2
+			}
+			if p.i+n > len(p.s) {
+				return "", p.errorf("truncated escape")
+			}
+			v, err := strconv.ParseUint(p.s[p.i:p.i+n], 16, 16)
+			if err != nil {
+				return "", p.errorf("invalid escape %q", p.s[p.i:p.i+n])
+			}
+			p.i += n
+			// Characters outside the basic plane are escaped as surrogate pairs which are decoded together.
+			units = append(units, uint16(v))
+			continue
+		case 'b':
+			esc = '\b'
+		case 'f':
+			esc = '\f'
+		case 'n':
+			esc = '\n'
+		case 'r':
+			esc = '\r'
+		case 't':
+			esc = '\t'
+		case 'v':
+			esc = '\v'
+		case '0':
+			esc = 0
+		}
+		flush()
+		b.WriteByte(esc)
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseNumber parses a number, including NaN and the infinities.
+func (p *gwtParser) parseNumber() (float64, error) {
+	start := p.i
+	for p.i < len(p.s) && strings.IndexByte("+-.0123456789eEINafinty", p.s[p.i]) >= 0 {
+		p.i++
+	}
+
+	n, err := strconv.ParseFloat(p.s[start:p.i], 64)
+	if err != nil || start == p.i {
+		return 0, p.errorf("invalid number %q", p.s[start:p.i])
+	}
+	return n, nil
+}
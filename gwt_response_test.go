@@ -0,0 +1,172 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestDecodeGWTResponse(t *testing.T) {
+	body := `//OK[1,-1,1,3,2,'XSHboAA',1.5,0,1,-300].concat([["com.example.Point/1234","a \"b\" \u00e9 \ud83d\ude00","tok|en"],0,7])`
+
+	r, err := gocronometer.DecodeGWTResponse(body)
+	if err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if r.Version != 7 || r.Flags != 0 || len(r.Strings) != 3 {
+		t.Fatalf("unexpected version %d, flags %d or strings %v", r.Version, r.Flags, r.Strings)
+	}
+
+	if v, err := r.ReadInt(); err != nil || v != -300 {
+		t.Fatalf("expected int -300 but received %d: %v", v, err)
+	}
+	if v, err := r.ReadBool(); err != nil || !v {
+		t.Fatalf("expected true but received %t: %v", v, err)
+	}
+	if v, err := r.ReadBool(); err != nil || v {
+		t.Fatalf("expected false but received %t: %v", v, err)
+	}
+	if v, err := r.ReadDouble(); err != nil || v != 1.5 {
+		t.Fatalf("expected 1.5 but received %f: %v", v, err)
+	}
+	if v, err := r.ReadLong(); err != nil || v != 1_600_000_000_000 {
+		t.Fatalf("expected long 1600000000000 but received %d: %v", v, err)
+	}
+	if v, err := r.ReadString(); err != nil || v != "a \"b\" é 😀" {
+		t.Fatalf("expected the escaped string to be decoded but received %q: %v", v, err)
+	}
+	if v, err := r.ReadString(); err != nil || v != "tok|en" {
+		t.Fatalf("expected tok|en but received %q: %v", v, err)
+	}
+	if typ, ref, err := r.ReadObjectType(); err != nil || typ != "com.example.Point/1234" || ref != 0 {
+		t.Fatalf("expected a new Point object but received %q %d: %v", typ, ref, err)
+	}
+	if typ, ref, err := r.ReadObjectType(); err != nil || typ != "com.example.Point/1234" || ref != 1 {
+		t.Fatalf("expected a reference to the Point object but received %q %d: %v", typ, ref, err)
+	}
+	if v, err := r.ReadInt(); err != nil || v != 1 {
+		t.Fatalf("expected int 1 but received %d: %v", v, err)
+	}
+
+	if r.Remaining() != 0 {
+		t.Fatalf("expected no values to remain but found %d", r.Remaining())
+	}
+	if _, err := r.ReadInt(); !errors.Is(err, gocronometer.ErrUnexpectedResponse) {
+		t.Fatalf("expected ErrUnexpectedResponse reading past the end but received %v", err)
+	}
+}
+
+func TestGWTReader_ReadLong_NotLong(t *testing.T) {
+	for _, body := range []string{`//OK[1.5,[],0,7]`, `//OK[1e30,[],0,7]`, `//OK[9223372036854775808,[],0,7]`} {
+		r, err := gocronometer.DecodeGWTResponse(body)
+		if err != nil {
+			t.Fatalf("failed to decode %s: %s", body, err)
+		}
+		if v, err := r.ReadLong(); !errors.Is(err, gocronometer.ErrUnexpectedResponse) {
+			t.Errorf("expected ErrUnexpectedResponse for %s but received %d: %v", body, v, err)
+		}
+	}
+
+	r, err := gocronometer.DecodeGWTResponse(`//OK[-9007199254740992,[],0,7]`)
+	if err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if v, err := r.ReadLong(); err != nil || v != -9007199254740992 {
+		t.Fatalf("expected long -9007199254740992 but received %d: %v", v, err)
+	}
+}
+
+func TestDecodeGWTResponse_Exception(t *testing.T) {
+	body := `//EX[2,1,["com.cronometer.shared.rpc.NotLoggedInException/3472318520","Not logged in"],0,7]`
+
+	_, err := gocronometer.DecodeGWTResponse(body)
+
+	var gwtErr *gocronometer.GWTError
+	if !errors.As(err, &gwtErr) {
+		t.Fatalf("expected a GWTError but received %v", err)
+	}
+	if gwtErr.Exception != "com.cronometer.shared.rpc.NotLoggedInException" || gwtErr.Message != "Not logged in" {
+		t.Fatalf("unexpected exception %q with message %q", gwtErr.Exception, gwtErr.Message)
+	}
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected the exception to match ErrSessionExpired")
+	}
+}
+
+func TestDecodeGWTResponse_Malformed(t *testing.T) {
+	bodies := []string{
+		`<html>Service Unavailable</html>`,
+		`//OK[1,["a"],0`,
+		`//OK[1,0,7]`,
+		`//OK[1,["a",2],0,7]`,
+		`//OK["unterminated],0,7]`,
+	}
+
+	for _, body := range bodies {
+		if _, err := gocronometer.DecodeGWTResponse(body); !errors.Is(err, gocronometer.ErrUnexpectedResponse) {
+			t.Errorf("expected ErrUnexpectedResponse for %s but received %v", body, err)
+		}
+	}
+}
+
+// TestDecodeGWTResponse_Fixtures decodes the responses in testdata/gwt. They hold the layout the client relies on, a
+// single value, with the user id and token values replaced. Responses captured from the web app should replace them
+// whenever the layout changes.
+func TestDecodeGWTResponse_Fixtures(t *testing.T) {
+	read := func(name string) *gocronometer.GWTReader {
+		body, err := os.ReadFile(filepath.Join("testdata", "gwt", name))
+		if err != nil {
+			t.Fatalf("failed to read fixture: %s", err)
+		}
+		r, err := gocronometer.DecodeGWTResponse(string(body))
+		if err != nil {
+			t.Fatalf("failed to decode %s: %s", name, err)
+		}
+		return r
+	}
+
+	r := read("authenticate.txt")
+	if userID, err := r.ReadInt(); err != nil || userID != 2741118 {
+		t.Fatalf("expected user id 2741118 but received %d: %v", userID, err)
+	}
+	if err := r.End(); err != nil {
+		t.Fatalf("expected the user id to be the only value: %s", err)
+	}
+
+	r = read("generateAuthorizationToken.txt")
+	if token, err := r.ReadString(); err != nil || token != "9f2c4e7a1b3d5f60a8c2e4f6b1d3a5c7" {
+		t.Fatalf("unexpected token %q: %v", token, err)
+	}
+	if err := r.End(); err != nil {
+		t.Fatalf("expected the token to be the only value: %s", err)
+	}
+}
+
+func TestClient_GWTAuthenticate_LayoutShift(t *testing.T) {
+	srv := newTestServer(t)
+
+	// The user id is returned boxed, so the value read first is a type reference rather than the user id.
+	opts := srv.ClientOptions()
+	opts.Hooks = []gocronometer.Hooks{{
+		AfterResponse: func(_ context.Context, op string, _ *http.Request, resp *http.Response, _ time.Duration) {
+			if op == gocronometer.OpGWTAuthenticate {
+				_ = resp.Body.Close()
+				resp.Body = io.NopCloser(strings.NewReader(`//OK[123456,1,["java.lang.Integer/3438268394"],0,7]`))
+			}
+		},
+	}}
+
+	err := gocronometer.NewClient(opts).Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrUnexpectedResponse) {
+		t.Fatalf("expected ErrUnexpectedResponse but received %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package gocronometer_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jrmycanady/gocronometer"
+)
+
+// encodeDefault encodes the call with the default module base and header.
+func encodeDefault(call gocronometer.GWTCall) string {
+	return call.Encode(gocronometer.GWTModuleBase, gocronometer.GWTHeader)
+}
+
+func TestGWTCall_Encode_KnownCalls(t *testing.T) {
+	const service = "com.cronometer.shared.rpc.CronometerService"
+	const authScope = "com.cronometer.shared.user.AuthScope/2065601159"
+
+	tests := []struct {
+		name     string
+		call     gocronometer.GWTCall
+		expected string
+	}{
+		{
+			name: "authenticate",
+			call: gocronometer.GWTCall{Service: service, Method: "authenticate", Params: []gocronometer.GWTParam{
+				{Type: gocronometer.GWTTypeInteger, Value: gocronometer.GWTInteger(-300)},
+			}},
+			expected: gocronometer.GWTAuthenticate,
+		},
+		{
+			name: "logout",
+			call: gocronometer.GWTCall{Service: service, Method: "logout", Params: []gocronometer.GWTParam{
+				{Type: gocronometer.GWTTypeString, Value: gocronometer.GWTString("0123456789abcdef")},
+			}},
+			expected: fmt.Sprintf(gocronometer.GWTLogout, "0123456789abcdef"),
+		},
+		{
+			name: "generateAuthorizationToken",
+			call: gocronometer.GWTCall{Service: service, Method: "generateAuthorizationToken", Params: []gocronometer.GWTParam{
+				{Type: gocronometer.GWTTypeString, Value: gocronometer.GWTString("0123456789abcdef")},
+				{Type: gocronometer.GWTTypeInt, Value: gocronometer.GWTInt(123456)},
+				{Type: gocronometer.GWTTypeInt, Value: gocronometer.GWTInt(3600)},
+				{Type: authScope, Value: gocronometer.GWTEnum(authScope, 2)},
+			}},
+			expected: fmt.Sprintf(gocronometer.GWTGenerateAuthToken, "0123456789abcdef", "123456"),
+		},
+	}
+
+	for _, test := range tests {
+		if body := encodeDefault(test.call); body != test.expected {
+			t.Errorf("%s: expected\n%s\nbut received\n%s", test.name, test.expected, body)
+		}
+	}
+}
+
+func TestGWTCall_Encode_Escaping(t *testing.T) {
+	call := gocronometer.GWTCall{Service: "S", Method: "m", Params: []gocronometer.GWTParam{
+		{Type: gocronometer.GWTTypeString, Value: gocronometer.GWTString(`a|b\c` + "\x00d")},
+	}}
+
+	expected := "7|0|6|b|h|S|m|java.lang.String/2004016611|a\\!b\\\\c\\0d|1|2|3|4|1|5|6|"
+	if body := call.Encode("b", "h"); body != expected {
+		t.Fatalf("expected %q but received %q", expected, body)
+	}
+}
+
+func TestGWTCall_Encode_Values(t *testing.T) {
+	const point = "com.example.Point/1234"
+	shared := &gocronometer.GWTObject{Type: point, Fields: []gocronometer.GWTValue{gocronometer.GWTInt(1), gocronometer.GWTInt(2)}}
+
+	call := gocronometer.GWTCall{Service: "S", Method: "m", Params: []gocronometer.GWTParam{
+		{Type: gocronometer.GWTTypeLong, Value: gocronometer.GWTLong(0)},
+		{Type: gocronometer.GWTTypeLong, Value: gocronometer.GWTLong(64)},
+		{Type: gocronometer.GWTTypeLong, Value: gocronometer.GWTLong(-1)},
+		{Type: gocronometer.GWTTypeLong, Value: gocronometer.GWTLong(1_600_000_000_000)},
+		{Type: gocronometer.GWTTypeDouble, Value: gocronometer.GWTDouble(1.5)},
+		{Type: gocronometer.GWTTypeDouble, Value: gocronometer.GWTDouble(math.Inf(1))},
+		{Type: gocronometer.GWTTypeDouble, Value: gocronometer.GWTDouble(math.Inf(-1))},
+		{Type: gocronometer.GWTTypeDouble, Value: gocronometer.GWTDouble(math.NaN())},
+		{Type: gocronometer.GWTTypeBoolean, Value: gocronometer.GWTBool(true)},
+		{Type: gocronometer.GWTTypeBoolean, Value: gocronometer.GWTBool(false)},
+		{Type: gocronometer.GWTTypeString, Value: gocronometer.GWTNull},
+		{Type: "java.util.List", Value: &gocronometer.GWTList{Elements: []gocronometer.GWTValue{
+			gocronometer.GWTBoxedString("x"), shared, shared,
+		}}},
+	}}
+
+	expected := "7|0|12|b|h|S|m|J|D|Z|java.lang.String/2004016611|java.util.List|java.util.ArrayList/4159755760|x|" +
+		point + "|1|2|3|4|12|5|5|5|5|6|6|6|6|7|7|8|9|" +
+		"A|BA|P__________|XSHboAA|1.5|Infinity|-Infinity|NaN|1|0|0|" +
+		"10|3|8|11|12|1|2|-3|"
+	if body := call.Encode("b", "h"); body != expected {
+		t.Fatalf("expected\n%s\nbut received\n%s", expected, body)
+	}
+}
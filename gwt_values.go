@@ -5,7 +5,7 @@ package gocronometer
 // default that will be used by the library if new values are not provided.
 const (
 	GWTContentType = "text/x-gwt-rpc; charset=UTF-8"
-	GWTModuleBase  = "https://cronometer.com/cronometer/"
+	GWTModuleBase  = DefaultBaseURL + gwtModulePath
 	GWTPermutation = "7B121DC5483BF272B1BC1916DA9FA963"
 
 	// GWTHeader is what appears to be a hash value that is provided at the beginning of every GWT request. As it
@@ -14,19 +14,59 @@ const (
 	GWTHeader = "2D6A926E3729946302DC68073CB0D550"
 )
 
-// The following are the GWT procedure calls as found from inspection of the app.
+// DefaultGWTTimezoneOffset is the timezone offset, in minutes east of UTC, provided when authenticating with the GWT
+// API if the client has no GWTLocation.
+const DefaultGWTTimezoneOffset = -300
+
+// The following are the GWT procedure calls as found from inspection of the app. They contain the default module base
+// and header.
+//
+// Deprecated: The client builds the calls from its own GWTModuleBase and GWTHeader, so these only reflect the library
+// defaults.
 const (
 
 	// GWTGenerateAuthToken will generate a GWT auth token. The only known use case is for accessing non GWT API calls
 	// such as data export.
 	// The first parameter in the string should be the sesnonce and the second is the users ID.
-	GWTGenerateAuthToken = "7|0|8|https://cronometer.com/cronometer/|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|generateAuthorizationToken" +
+	GWTGenerateAuthToken = "7|0|8|" + GWTModuleBase + "|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|generateAuthorizationToken" +
 		"|java.lang.String/2004016611|I|com.cronometer.shared.user.AuthScope/2065601159|%s|1|2|3|4|4|5|6|6|7|8|%s|3600|7|2|"
 
 	// GWTAuthenticate will authenticate with the GWT api. The sesnonce should be set in the cookies.
-	GWTAuthenticate = "7|0|5|https://cronometer.com/cronometer/|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|authenticate|java.lang.Integer/3438268394|1|2|3|4|1|5|5|-300|"
+	GWTAuthenticate = "7|0|5|" + GWTModuleBase + "|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|authenticate|java.lang.Integer/3438268394|1|2|3|4|1|5|5|-300|"
 
 	// GWTLogout will log the session out.
 	// The only parameter should be the sesnonce.
-	GWTLogout = "7|0|6|https://cronometer.com/cronometer/|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|logout|java.lang.String/2004016611|%s|1|2|3|4|1|5|6|"
+	GWTLogout = "7|0|6|" + GWTModuleBase + "|" + GWTHeader + "|com.cronometer.shared.rpc.CronometerService|logout|java.lang.String/2004016611|%s|1|2|3|4|1|5|6|"
+)
+
+// The following are the names and types of the GWT service the client calls.
+const (
+	gwtCronometerService = "com.cronometer.shared.rpc.CronometerService"
+	gwtTypeAuthScope     = "com.cronometer.shared.user.AuthScope/2065601159"
 )
+
+// gwtGenerateAuthTokenCall is the call generating an export token for the sesnonce and user ID with the lifetime in
+// seconds and scope provided.
+func gwtGenerateAuthTokenCall(nonce string, userID int32, lifetime int32, scope AuthScope) GWTCall {
+	return GWTCall{Service: gwtCronometerService, Method: "generateAuthorizationToken", Params: []GWTParam{
+		{Type: GWTTypeString, Value: GWTString(nonce)},
+		{Type: GWTTypeInt, Value: GWTInt(userID)},
+		{Type: GWTTypeInt, Value: GWTInt(lifetime)},
+		{Type: gwtTypeAuthScope, Value: GWTEnum(gwtTypeAuthScope, int32(scope))},
+	}}
+}
+
+// gwtAuthenticateCall is the call authenticating the session of the sesnonce cookie with the timezone offset in
+// minutes provided.
+func gwtAuthenticateCall(offset int32) GWTCall {
+	return GWTCall{Service: gwtCronometerService, Method: "authenticate", Params: []GWTParam{
+		{Type: GWTTypeInteger, Value: GWTInteger(offset)},
+	}}
+}
+
+// gwtLogoutCall is the call logging out the session of the sesnonce.
+func gwtLogoutCall(nonce string) GWTCall {
+	return GWTCall{Service: gwtCronometerService, Method: "logout", Params: []GWTParam{
+		{Type: GWTTypeString, Value: GWTString(nonce)},
+	}}
+}
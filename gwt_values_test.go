@@ -0,0 +1,47 @@
+package gocronometer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_GWTHeaderOverride(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetGWTHeader(testHeader)
+
+	opts := srv.ClientOptions()
+	opts.GWTHeader = testHeader
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login with overridden header: %s", err)
+	}
+
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export with overridden header: %s", err)
+	}
+
+	if err := client.Logout(context.Background()); err != nil {
+		t.Fatalf("failed to logout with overridden header: %s", err)
+	}
+}
+
+func TestClient_GWTLocation(t *testing.T) {
+	srv := newTestServer(t)
+
+	opts := srv.ClientOptions()
+	opts.GWTLocation = time.FixedZone("UTC+2", 2*60*60)
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	if offset := srv.TimezoneOffset(); offset != 120 {
+		t.Fatalf("expected timezone offset of 120 but found %d", offset)
+	}
+}
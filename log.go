@@ -0,0 +1,21 @@
+package gocronometer
+
+import (
+	"log/slog"
+)
+
+// discardLogger is the logger of clients without one.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// logger returns the logger of the client or a logger that discards everything if it has none.
+func (c *Client) logger() *slog.Logger {
+	if c.Logger == nil {
+		return discardLogger
+	}
+	return c.Logger
+}
+
+// errAttr returns the error as a log attribute with secrets redacted.
+func (c *Client) errAttr(err error) slog.Attr {
+	return slog.String("error", c.Redact(err.Error()))
+}
@@ -0,0 +1,61 @@
+package gocronometer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_Logger(t *testing.T) {
+	srv := newTestServer(t)
+
+	buf := &bytes.Buffer{}
+	opts := srv.ClientOptions()
+	opts.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+	if _, err := client.ExportServings(context.Background(), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	events := make(map[string]map[string]any)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("failed to parse log line %s: %s", line, err)
+		}
+		events[event["msg"].(string)] = event
+	}
+
+	for _, msg := range []string{"anticsrf obtained", "login posted", "gwt authenticated", "logged in", "export token generated", "export started", "export finished"} {
+		if _, ok := events[msg]; !ok {
+			t.Fatalf("expected a %q event in:\n%s", msg, buf.String())
+		}
+	}
+
+	if events["logged in"]["userid"] != client.SessionUserID() {
+		t.Fatalf("expected logged in event to carry the user id")
+	}
+
+	finished := events["export finished"]
+	if finished["kind"] != "servings" || finished["start"] != "2021-06-01" || finished["bytes"].(float64) == 0 {
+		t.Fatalf("export finished event was missing details: %v", finished)
+	}
+	if _, ok := finished["duration"]; !ok {
+		t.Fatalf("export finished event was missing the duration: %v", finished)
+	}
+
+	if strings.Contains(buf.String(), client.SessionNonce()) || strings.Contains(buf.String(), gocronometertest.DefaultPassword+`"`) {
+		t.Fatalf("secrets were logged:\n%s", buf.String())
+	}
+}
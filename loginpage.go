@@ -0,0 +1,107 @@
+package gocronometer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxLoginPageSize is the maximum number of bytes of a login page that are read.
+const maxLoginPageSize = 1 << 20
+
+// maxLoginPageSnapshot is the maximum number of bytes of the snapshot of an unexpected login page.
+const maxLoginPageSnapshot = 4096
+
+// loginPageRule classifies a login page whose body matches pattern as reason.
+type loginPageRule struct {
+	reason  error
+	pattern *regexp.Regexp
+}
+
+// loginPageRules are the rules classifying a login page without an anticsrf value in order of precedence.
+var loginPageRules = []loginPageRule{
+	{ErrBotChallenge, regexp.MustCompile(`(?i)cf-chl|challenge-platform|just a moment\.\.\.|attention required!|checking your browser`)},
+	{ErrCaptchaRequired, regexp.MustCompile(`(?i)g-recaptcha|h-captcha|cf-turnstile|captcha`)},
+	{ErrAccountLocked, regexp.MustCompile(`(?i)account (?:is|has been) (?:temporarily )?(?:locked|suspended|disabled)|too many (?:failed )?(?:login )?attempts`)},
+	{ErrMaintenance, regexp.MustCompile(`(?i)maintenance|be right back|temporarily unavailable`)},
+}
+
+// snapshotRedactRegex matches the email and IP addresses a login page may echo back, such as those on bot challenge
+// pages.
+var snapshotRedactRegex = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+|\b\d{1,3}(?:\.\d{1,3}){3}\b`)
+
+// classifyLoginPage returns the reason the login page in resp with the body provided did not provide an anticsrf value.
+// Nil is returned for a non 200 response that could not be classified. A 200 response that could not be classified
+// is assumed to be a login form that changed.
+func classifyLoginPage(resp *http.Response, body []byte) error {
+	if strings.EqualFold(resp.Header.Get("cf-mitigated"), "challenge") {
+		return ErrBotChallenge
+	}
+
+	for _, rule := range loginPageRules {
+		if rule.pattern.Match(body) {
+			return rule.reason
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return ErrLoginFormChanged
+	}
+
+	return nil
+}
+
+// loginPageError returns a LoginPageError of the login page in resp classified as reason with a redacted snapshot of
+// its body.
+func (c *Client) loginPageError(ctx context.Context, op string, resp *http.Response, body []byte, reason error) error {
+	err := &LoginPageError{
+		Op:         strings.ReplaceAll(op, ".", " "),
+		Reason:     reason,
+		StatusCode: resp.StatusCode,
+		Title:      pageTitle(body),
+		Snapshot:   c.pageSnapshot(body),
+	}
+
+	c.logger().WarnContext(ctx, "unexpected login page", slog.String("op", op), slog.String("reason", reason.Error()),
+		slog.Int("status", resp.StatusCode), slog.String("title", err.Title))
+
+	return err
+}
+
+// pageSnapshot returns the body of a page with the secrets of the client, email addresses and IP addresses redacted,
+// truncated to maxLoginPageSnapshot bytes.
+func (c *Client) pageSnapshot(body []byte) string {
+	snapshot := snapshotRedactRegex.ReplaceAllString(c.Redact(string(body)), Redacted)
+	if len(snapshot) <= maxLoginPageSnapshot {
+		return snapshot
+	}
+
+	return strings.ToValidUTF8(snapshot[:maxLoginPageSnapshot], "") + "... snapshot truncated"
+}
+
+// pageTitle returns the title of the HTML page in body or an empty string if it has none.
+func pageTitle(body []byte) string {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var title string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "title" && n.FirstChild != nil && title == "" {
+			title = strings.TrimSpace(n.FirstChild.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+
+	return title
+}
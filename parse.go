@@ -1,6 +1,7 @@
 package gocronometer
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -90,6 +91,18 @@ type ServingsExport struct {
 }
 
 func ParseServingsExport(rawCSVReader io.Reader, location *time.Location) (ServingRecords, error) {
+	return parseServingsExport(rawCSVReader, location, nil)
+}
+
+// ParseServingsExportContext is ParseServingsExport reporting the records parsed to the progress function of ctx, if
+// any, and stopping with the error of ctx once it is done.
+func ParseServingsExportContext(ctx context.Context, rawCSVReader io.Reader, location *time.Location) (ServingRecords, error) {
+	return parseServingsExport(rawCSVReader, location, parseProgress(ctx, ExportKindServings, DateWindow{}))
+}
+
+// parseServingsExport is ParseServingsExport with onRecord, if not nil, called with the number of records parsed after
+// every record. Parsing stops with the error onRecord returns.
+func parseServingsExport(rawCSVReader io.Reader, location *time.Location, onRecord func(records int) error) (ServingRecords, error) {
 
 	r := csv.NewReader(rawCSVReader)
 
@@ -557,6 +570,11 @@ func ParseServingsExport(rawCSVReader io.Reader, location *time.Location) (Servi
 			return nil, fmt.Errorf("parsing record time: %s", err)
 		}
 		servings = append(servings, serving)
+		if onRecord != nil {
+			if err := onRecord(len(servings)); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return servings, nil
@@ -582,6 +600,18 @@ type ExerciseRecord struct {
 type ExerciseRecords []ExerciseRecord
 
 func ParseExerciseExport(rawCSVReader io.Reader, location *time.Location) (ExerciseRecords, error) {
+	return parseExerciseExport(rawCSVReader, location, nil)
+}
+
+// ParseExerciseExportContext is ParseExerciseExport reporting the records parsed to the progress function of ctx, if
+// any, and stopping with the error of ctx once it is done.
+func ParseExerciseExportContext(ctx context.Context, rawCSVReader io.Reader, location *time.Location) (ExerciseRecords, error) {
+	return parseExerciseExport(rawCSVReader, location, parseProgress(ctx, ExportKindExercises, DateWindow{}))
+}
+
+// parseExerciseExport is ParseExerciseExport with onRecord, if not nil, called with the number of records parsed after
+// every record. Parsing stops with the error onRecord returns.
+func parseExerciseExport(rawCSVReader io.Reader, location *time.Location, onRecord func(records int) error) (ExerciseRecords, error) {
 
 	r := csv.NewReader(rawCSVReader)
 
@@ -650,6 +680,11 @@ func ParseExerciseExport(rawCSVReader io.Reader, location *time.Location) (Exerc
 			return nil, fmt.Errorf("parsing record time: %s", err)
 		}
 		exercises = append(exercises, exercise)
+		if onRecord != nil {
+			if err := onRecord(len(exercises)); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return exercises, nil
@@ -666,6 +701,18 @@ type BiometricRecord struct {
 type BiometricRecords []BiometricRecord
 
 func ParseBiometricRecordsExport(rawCSVReader io.Reader, location *time.Location) (BiometricRecords, error) {
+	return parseBiometricRecordsExport(rawCSVReader, location, nil)
+}
+
+// ParseBiometricRecordsExportContext is ParseBiometricRecordsExport reporting the records parsed to the progress
+// function of ctx, if any, and stopping with the error of ctx once it is done.
+func ParseBiometricRecordsExportContext(ctx context.Context, rawCSVReader io.Reader, location *time.Location) (BiometricRecords, error) {
+	return parseBiometricRecordsExport(rawCSVReader, location, parseProgress(ctx, ExportKindBiometrics, DateWindow{}))
+}
+
+// parseBiometricRecordsExport is ParseBiometricRecordsExport with onRecord, if not nil, called with the number of
+// records parsed after every record. Parsing stops with the error onRecord returns.
+func parseBiometricRecordsExport(rawCSVReader io.Reader, location *time.Location, onRecord func(records int) error) (BiometricRecords, error) {
 
 	r := csv.NewReader(rawCSVReader)
 
@@ -730,6 +777,11 @@ func ParseBiometricRecordsExport(rawCSVReader io.Reader, location *time.Location
 			return nil, fmt.Errorf("parsing record time: %s", err)
 		}
 		records = append(records, bioRecord)
+		if onRecord != nil {
+			if err := onRecord(len(records)); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return records, nil
@@ -0,0 +1,101 @@
+package gocronometer
+
+import "context"
+
+// ProgressPhase is the step a reported operation is in.
+type ProgressPhase string
+
+// The following are the phases reported to a ProgressFunc.
+const (
+	// PhaseAuthenticating is reported when logging in or renewing an expired session.
+	PhaseAuthenticating ProgressPhase = "authenticating"
+
+	// PhaseToken is reported when an export token is generated.
+	PhaseToken ProgressPhase = "token"
+
+	// PhaseDownloading is reported as the body of an export is received.
+	PhaseDownloading ProgressPhase = "downloading"
+
+	// PhaseParsing is reported as the records of an export are parsed.
+	PhaseParsing ProgressPhase = "parsing"
+
+	// PhaseWindowDone is reported when a window of a chunked export or backfill has completed.
+	PhaseWindowDone ProgressPhase = "window done"
+)
+
+// Progress is a report of the progress of an operation. Only the fields relevant to the phase are set.
+type Progress struct {
+	Phase ProgressPhase
+
+	// Kind and Window are the kind and date range of the export the report belongs to.
+	Kind   ExportKind
+	Window DateWindow
+
+	// BytesReceived is the number of bytes of the export body received so far. BytesTotal is the Content-Length of
+	// the export response or -1 if it is unknown.
+	BytesReceived int64
+	BytesTotal    int64
+
+	// Records is the number of records parsed so far.
+	Records int
+
+	// WindowsCompleted is the number of windows completed out of WindowsTotal.
+	WindowsCompleted int
+	WindowsTotal     int
+}
+
+// ProgressFunc receives progress reports. It is called synchronously from the goroutine doing the work, which may be
+// one of several for chunked and bundled exports, so it should return quickly and be safe for concurrent use.
+type ProgressFunc func(Progress)
+
+// ProgressChannel returns a ProgressFunc that sends every report to ch. Reports are dropped rather than blocking the
+// operation when ch is full.
+func ProgressChannel(ch chan<- Progress) ProgressFunc {
+	return func(p Progress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// progressKey is the context key of the progress function.
+type progressKey struct{}
+
+// WithProgress returns a context that reports the progress of every operation of the client it is provided to, such
+// as exports, chunked exports, ExportAll and Backfill, to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress reports p to the progress function carried by ctx, if any.
+func reportProgress(ctx context.Context, p Progress) {
+	if fn, _ := ctx.Value(progressKey{}).(ProgressFunc); fn != nil {
+		fn(p)
+	}
+}
+
+// hasProgress returns true if ctx carries a progress function.
+func hasProgress(ctx context.Context) bool {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn != nil
+}
+
+// parseCancelInterval is the number of records parsed between checks of the context.
+const parseCancelInterval = 100
+
+// parseProgress returns the onRecord function of the parsers that reports the records parsed for the export of the
+// kind and window to the progress function of ctx, if any, and stops parsing once ctx is done. The context is checked
+// every parseCancelInterval records.
+func parseProgress(ctx context.Context, kind ExportKind, window DateWindow) func(records int) error {
+	report := hasProgress(ctx)
+	return func(records int) error {
+		if report {
+			reportProgress(ctx, Progress{Phase: PhaseParsing, Kind: kind, Window: window, Records: records})
+		}
+		if records%parseCancelInterval == 0 {
+			return ctx.Err()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,145 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// progressRecorder records every progress report it receives.
+type progressRecorder struct {
+	mu      sync.Mutex
+	reports []gocronometer.Progress
+}
+
+func (r *progressRecorder) report(p gocronometer.Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, p)
+}
+
+// last returns the last report of the phase and whether there was one.
+func (r *progressRecorder) last(phase gocronometer.ProgressPhase) (gocronometer.Progress, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.reports) - 1; i >= 0; i-- {
+		if r.reports[i].Phase == phase {
+			return r.reports[i], true
+		}
+	}
+	return gocronometer.Progress{}, false
+}
+
+func TestClient_Progress(t *testing.T) {
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	recorder := &progressRecorder{}
+	ctx := gocronometer.WithProgress(context.Background(), recorder.report)
+
+	if err := client.Login(ctx, gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	servings, err := client.ExportServingsChunked(ctx, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC), time.UTC, gocronometer.ChunkOptions{Days: 2, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("failed to export servings: %s", err)
+	}
+
+	if _, ok := recorder.last(gocronometer.PhaseAuthenticating); !ok {
+		t.Fatalf("expected the login to be reported")
+	}
+	if _, ok := recorder.last(gocronometer.PhaseToken); !ok {
+		t.Fatalf("expected the token generation to be reported")
+	}
+
+	download, ok := recorder.last(gocronometer.PhaseDownloading)
+	if !ok || download.Kind != gocronometer.ExportKindServings || download.BytesReceived == 0 {
+		t.Fatalf("expected the download to be reported but found %+v", download)
+	}
+	if download.BytesTotal != -1 && download.BytesReceived != download.BytesTotal {
+		t.Fatalf("expected %d bytes to be received but found %d", download.BytesTotal, download.BytesReceived)
+	}
+
+	parsing, ok := recorder.last(gocronometer.PhaseParsing)
+	if !ok || parsing.Records != 1 || len(servings) != 2 {
+		t.Fatalf("expected at most one serving to be parsed per window but found %+v", parsing)
+	}
+
+	window, ok := recorder.last(gocronometer.PhaseWindowDone)
+	if !ok || window.WindowsCompleted != 5 || window.WindowsTotal != 5 {
+		t.Fatalf("expected 5 of 5 windows to be completed but found %+v", window)
+	}
+}
+
+func TestProgressChannel(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+
+	// A full channel drops reports rather than blocking the export.
+	ch := make(chan gocronometer.Progress, 1)
+	ctx := gocronometer.WithProgress(context.Background(), gocronometer.ProgressChannel(ch))
+
+	if _, err := client.ExportNotes(ctx, time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export notes: %s", err)
+	}
+
+	if p := <-ch; p.Phase == "" {
+		t.Fatalf("expected a progress report but found %+v", p)
+	}
+}
+
+func TestClient_Progress_Cancelled(t *testing.T) {
+	_, client := loggedInClient(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = gocronometer.WithProgress(ctx, func(p gocronometer.Progress) {
+		if p.Phase == gocronometer.PhaseWindowDone {
+			cancel()
+		}
+	})
+
+	_, err := client.ExportChunked(ctx, gocronometer.ExportKindServings, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC), gocronometer.ChunkOptions{Days: 1})
+
+	var chunkErr *gocronometer.ChunkError
+	if !errors.As(err, &chunkErr) || len(chunkErr.Failed) != 9 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the windows after the cancellation to fail but received %v", err)
+	}
+}
+
+func TestParseServingsExportContext(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	day := time.Date(2021, 7, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 250; i++ {
+		srv.AddServings(gocronometer.ServingRecord{RecordedTime: day, Group: "Snacks", FoodName: "Almond",
+			QuantityValue: 1, QuantityUnits: "g"})
+	}
+	raw, err := client.ExportServings(context.Background(), day, day)
+	if err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	recorder := &progressRecorder{}
+	ctx := gocronometer.WithProgress(context.Background(), recorder.report)
+	servings, err := gocronometer.ParseServingsExportContext(ctx, strings.NewReader(raw), time.UTC)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if p, ok := recorder.last(gocronometer.PhaseParsing); !ok || p.Records != len(servings) || len(servings) != 250 {
+		t.Fatalf("expected 250 records parsed to be reported but received %+v for %d servings", p, len(servings))
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = gocronometer.ParseServingsExportContext(cancelled, strings.NewReader(raw), time.UTC); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled but received %v", err)
+	}
+}
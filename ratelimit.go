@@ -0,0 +1,73 @@
+package gocronometer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The following are the limits of the rate limiter a client is created with unless another is provided. They keep the
+// client polite towards the unpublished API.
+const (
+	// DefaultRateLimit is the sustained number of requests per second.
+	DefaultRateLimit = 2
+
+	// DefaultRateBurst is the number of requests that may be made at once before the limit applies.
+	DefaultRateBurst = 5
+)
+
+// RateLimiter is a token bucket rate limiter. It is safe for concurrent use and may be shared by multiple clients so
+// they are limited together.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter allowing rate requests per second with bursts of up to burst requests. The
+// bucket starts full.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request is allowed or ctx is done. The error of ctx is returned if it is done first.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// The token is reserved before waiting so concurrent waiters queue behind each other.
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := gocronometer.NewRateLimiter(50, 2)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two requests are allowed by the burst and the remaining three wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("expected requests to be limited but 5 completed in %s", elapsed)
+	}
+}
+
+func TestRateLimiter_WaitContext(t *testing.T) {
+	limiter := gocronometer.NewRateLimiter(0.1, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to end with the context but received %v", err)
+	}
+}
+
+func TestClient_RateLimiter_Shared(t *testing.T) {
+	srv := newTestServer(t)
+
+	opts := srv.ClientOptions()
+	opts.DisableRateLimit = false
+	opts.RateLimiter = gocronometer.NewRateLimiter(20, 1)
+
+	first := gocronometer.NewClient(opts)
+	second := gocronometer.NewClient(opts)
+
+	start := time.Now()
+	for _, c := range []*gocronometer.Client{first, second, first, second} {
+		if _, err := c.ObtainAntiCSRF(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("expected clients sharing a limiter to be limited together but 4 requests completed in %s", elapsed)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLoginPage); n != 4 {
+		t.Fatalf("expected 4 requests but found %d", n)
+	}
+}
+
+// countingTransport counts the requests it executes with http.DefaultTransport.
+type countingTransport struct {
+	n atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_Transport_Limited(t *testing.T) {
+	srv := newTestServer(t)
+
+	transport := &countingTransport{}
+	hooked := atomic.Int32{}
+
+	opts := srv.ClientOptions()
+	opts.DisableRateLimit = false
+	opts.RateLimiter = gocronometer.NewRateLimiter(20, 1)
+	opts.Transport = transport
+	opts.Hooks = []gocronometer.Hooks{{BeforeRequest: func(context.Context, string, *http.Request) { hooked.Add(1) }}}
+	client := gocronometer.NewClient(opts)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.ObtainAntiCSRF(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected requests through the transport to be limited but 3 requests completed in %s", elapsed)
+	}
+	if n := transport.n.Load(); n != 3 {
+		t.Fatalf("expected 3 requests through the transport but found %d", n)
+	}
+	if n := hooked.Load(); n != 3 {
+		t.Fatalf("expected the hooks to be called for 3 requests but found %d", n)
+	}
+}
@@ -0,0 +1,197 @@
+package gocronometer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces secret values removed by Client.Redact.
+const Redacted = "[REDACTED]"
+
+// maxRedactedTokens is the number of export tokens the client remembers for redaction. Older tokens are forgotten as
+// new ones are generated.
+const maxRedactedTokens = 32
+
+// maxDebugBody is the maximum number of bytes of a body written by the debug hooks.
+const maxDebugBody = 4096
+
+// secretFieldRegex matches the form fields, query parameters and cookies that carry secrets.
+var secretFieldRegex = regexp.MustCompile(`(?i)\b(password|anticsrf|nonce|sesnonce)=([^&;\s"]*)`)
+
+// secretInputRegex matches the value of the HTML inputs that carry secrets.
+var secretInputRegex = regexp.MustCompile(`(?i)(name=["']?(?:anticsrf|password)["']?[^>]*?\svalue=["']?)([^"'\s>]*)`)
+
+// The following are the kinds of secret values pinned by the redactor. Only the current value of each kind is kept.
+const (
+	secretPassword       = "password"
+	secretAntiCSRF       = "anticsrf"
+	secretTwoFactorCode  = "two-factor code"
+	secretNonce          = "nonce"
+	secretSessionCookies = "session cookies"
+)
+
+// redactor remembers the secret values seen by the client so they can be removed from logs and errors. The current
+// value of every kind of secret is pinned while export tokens, which are generated throughout a session, are rotated
+// out once maxRedactedTokens newer ones have been seen.
+type redactor struct {
+	mu     sync.Mutex
+	pinned map[string][]string
+	tokens []string
+}
+
+// pin replaces the secret values of the kind with the values provided. Values shorter than 4 characters are ignored
+// as they would redact unrelated text.
+func (r *redactor) pin(kind string, values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pinned == nil {
+		r.pinned = make(map[string][]string)
+	}
+
+	kept := make([]string, 0, len(values))
+	for _, v := range values {
+		if len(v) >= 4 && !slices.Contains(kept, v) {
+			kept = append(kept, v)
+		}
+	}
+	r.pinned[kind] = kept
+}
+
+// add remembers the export tokens provided, forgetting the oldest once there are more than maxRedactedTokens. A token
+// seen again becomes the newest. Values shorter than 4 characters are ignored as they would redact unrelated text.
+func (r *redactor) add(tokens ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range tokens {
+		if len(v) < 4 {
+			continue
+		}
+		r.tokens = slices.DeleteFunc(r.tokens, func(token string) bool { return token == v })
+		r.tokens = append(r.tokens, v)
+	}
+	if len(r.tokens) > maxRedactedTokens {
+		r.tokens = slices.Delete(r.tokens, 0, len(r.tokens)-maxRedactedTokens)
+	}
+}
+
+// redact replaces every remembered secret value in s. Longer values are replaced first so a value containing another
+// is not left partially redacted.
+func (r *redactor) redact(s string) string {
+	r.mu.Lock()
+	secrets := slices.Clone(r.tokens)
+	for _, values := range r.pinned {
+		secrets = append(secrets, values...)
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// Redact removes the secrets known to the client from s. The password and anticsrf form fields and inputs, the sesnonce
+// cookie and the export nonce are replaced with Redacted, as is any occurrence of the session nonce, password, anticsrf
+// value or export tokens the client has seen.
+func (c *Client) Redact(s string) string {
+	s = secretFieldRegex.ReplaceAllString(s, "${1}="+Redacted)
+	s = secretInputRegex.ReplaceAllString(s, "${1}"+Redacted)
+	return c.secrets.redact(s)
+}
+
+// DebugHooks returns hooks that write every request and response, including bodies, to w with secrets redacted. Bodies
+// longer than 4096 bytes are truncated. Only the start of the response body that is written is read ahead, the client
+// reads the rest from the connection as usual.
+func (c *Client) DebugHooks(w io.Writer) Hooks {
+	mu := &sync.Mutex{}
+
+	return Hooks{
+		BeforeRequest: func(_ context.Context, op string, req *http.Request) {
+			body := ""
+			if req.GetBody != nil {
+				if rc, err := req.GetBody(); err == nil {
+					b, _ := io.ReadAll(io.LimitReader(rc, maxDebugBody+1))
+					_ = rc.Close()
+					body = string(b)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			_, _ = fmt.Fprintf(w, "--> %s %s [%s]\n", req.Method, c.Redact(req.URL.String()), op)
+			c.writeDebugHeaders(w, req.Header)
+			c.writeDebugBody(w, body)
+		},
+		AfterResponse: func(_ context.Context, op string, req *http.Request, resp *http.Response, elapsed time.Duration) {
+			// Only the part of the body that is written is read ahead so a large export is still streamed.
+			body, err := io.ReadAll(io.LimitReader(resp.Body, maxDebugBody+1))
+			resp.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+
+			// The export token is in the string table of the response before the client has seen it.
+			logged := string(body)
+			if op == OpGWTGenerateToken {
+				if r, err := DecodeGWTResponse(logged); err == nil {
+					c.secrets.add(r.Strings...)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			_, _ = fmt.Fprintf(w, "<-- %d %s [%s] (%s)\n", resp.StatusCode, c.Redact(req.URL.String()), op, elapsed)
+			c.writeDebugHeaders(w, resp.Header)
+			if err != nil {
+				_, _ = fmt.Fprintf(w, "failed to read body: %s\n", c.Redact(err.Error()))
+			}
+			c.writeDebugBody(w, logged)
+		},
+		OnError: func(_ context.Context, op string, req *http.Request, err error, elapsed time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			_, _ = fmt.Fprintf(w, "<-- error %s [%s] (%s): %s\n", c.Redact(req.URL.String()), op, elapsed, c.Redact(err.Error()))
+		},
+	}
+}
+
+// prefixedBody is a response body whose start has been read ahead. It reads the start followed by the rest of the
+// original body and closes the original body.
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// writeDebugHeaders writes the headers to w with secrets redacted.
+func (c *Client) writeDebugHeaders(w io.Writer, header http.Header) {
+	for name, values := range header {
+		for _, v := range values {
+			_, _ = fmt.Fprintf(w, "%s: %s\n", name, c.Redact(v))
+		}
+	}
+}
+
+// writeDebugBody writes the body to w with secrets redacted, truncating it if needed.
+func (c *Client) writeDebugBody(w io.Writer, body string) {
+	if body == "" {
+		return
+	}
+
+	truncated := len(body) > maxDebugBody
+	if truncated {
+		body = body[:maxDebugBody]
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s\n", c.Redact(body))
+	if truncated {
+		_, _ = fmt.Fprintln(w, "... body truncated")
+	}
+}
@@ -0,0 +1,195 @@
+package gocronometer_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_Hooks(t *testing.T) {
+	srv := newTestServer(t)
+
+	mu := sync.Mutex{}
+	var before, after []string
+
+	opts := srv.ClientOptions()
+	opts.Hooks = []gocronometer.Hooks{{
+		BeforeRequest: func(_ context.Context, op string, _ *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			before = append(before, op)
+		},
+		AfterResponse: func(_ context.Context, op string, _ *http.Request, _ *http.Response, _ time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			after = append(after, op)
+		},
+	}}
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+	if _, err := client.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	expected := []string{gocronometer.OpLoginPage, gocronometer.OpLogin, gocronometer.OpGWTAuthenticate,
+		gocronometer.OpGWTGenerateToken, "export.servings"}
+
+	if strings.Join(before, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected operations %v before requests but found %v", expected, before)
+	}
+	if strings.Join(after, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected operations %v after responses but found %v", expected, after)
+	}
+}
+
+func TestClient_DebugWriter_Redacts(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetCredentials(gocronometertest.DefaultUsername, "s3cret-passw0rd")
+
+	buf := &bytes.Buffer{}
+	opts := srv.ClientOptions()
+	opts.DebugWriter = buf
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, "s3cret-passw0rd"); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+	if _, err := client.ExportServings(context.Background(), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	log := buf.String()
+
+	if !strings.Contains(log, "[export.servings]") || !strings.Contains(log, "Oatmeal") {
+		t.Fatalf("expected the export request and response body to be logged:\n%s", log)
+	}
+
+	if strings.Contains(log, "s3cret-passw0rd") {
+		t.Fatalf("password was logged:\n%s", log)
+	}
+	if strings.Contains(log, client.SessionNonce()) {
+		t.Fatalf("sesnonce was logged:\n%s", log)
+	}
+
+	// Every other 32 character hex value is an anticsrf value or export token.
+	if secret := regexp.MustCompile(`[0-9a-f]{32}`).FindString(log); secret != "" {
+		t.Fatalf("secret %s was logged:\n%s", secret, log)
+	}
+}
+
+func TestClient_Redact(t *testing.T) {
+	client := gocronometer.NewClient(nil)
+
+	redacted := client.Redact(`username=me&password=hunter2&anticsrf=abc; sesnonce=def /export?nonce=ghi&generate=servings <input type="hidden" name="anticsrf" value="jkl">`)
+	expected := `username=me&password=[REDACTED]&anticsrf=[REDACTED]; sesnonce=[REDACTED] /export?nonce=[REDACTED]&generate=servings <input type="hidden" name="anticsrf" value="[REDACTED]">`
+
+	if redacted != expected {
+		t.Fatalf("expected %s but found %s", expected, redacted)
+	}
+}
+
+func TestClient_DebugWriter_RedactsNonceAfterTokenRefreshes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_, client := loggedInClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.DebugWriter = buf
+	})
+	nonce := client.SessionNonce()
+
+	// Export tokens are forgotten as new ones are generated but the nonce of the session is not.
+	for i := 0; i < 40; i++ {
+		if _, err := client.GenerateAuthToken(context.Background()); err != nil {
+			t.Fatalf("failed to generate token: %s", err)
+		}
+	}
+	if err := client.Logout(context.Background()); err != nil {
+		t.Fatalf("failed to logout: %s", err)
+	}
+
+	if strings.Contains(buf.String(), nonce) {
+		t.Fatalf("sesnonce was logged after the tokens were refreshed")
+	}
+	if redacted := client.Redact(nonce); redacted != gocronometer.Redacted {
+		t.Fatalf("expected the sesnonce to be redacted but found %s", redacted)
+	}
+}
+
+// readCountingTransport counts the bytes read from the bodies of the responses of http.DefaultTransport.
+type readCountingTransport struct {
+	n atomic.Int64
+}
+
+func (t *readCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil {
+		resp.Body = readCountingBody{ReadCloser: resp.Body, n: &t.n}
+	}
+	return resp, err
+}
+
+type readCountingBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b readCountingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+func TestClient_DebugWriter_StreamsExports(t *testing.T) {
+	buf := &bytes.Buffer{}
+	transport := &readCountingTransport{}
+	srv, client := loggedInClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.DebugWriter = buf
+		opts.Transport = transport
+	})
+
+	day := time.Date(2021, 7, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 1000; i++ {
+		srv.AddServings(gocronometer.ServingRecord{RecordedTime: day, Group: "Breakfast", FoodName: "Oatmeal",
+			QuantityValue: 1, QuantityUnits: "cup", EnergyKcal: 150})
+	}
+
+	// The export token is generated before counting so only the export is counted.
+	if _, err := client.ExportNotes(context.Background(), day, day); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	transport.n.Store(0)
+	body, err := client.ExportStream(context.Background(), gocronometer.ExportKindServings, day, day)
+	if err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer body.Close()
+
+	// Only the start of the export written to the log has been read before the client reads it.
+	if n := transport.n.Load(); n > 4097 {
+		t.Fatalf("expected at most 4097 bytes to be read ahead but %d were", n)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to read export: %s", err)
+	}
+	if n := strings.Count(string(data), "Oatmeal"); n != 1000 {
+		t.Fatalf("expected 1000 servings to be exported but found %d", n)
+	}
+	if !strings.Contains(buf.String(), "... body truncated") {
+		t.Fatalf("expected the logged export to be truncated")
+	}
+}
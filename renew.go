@@ -0,0 +1,84 @@
+package gocronometer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CredentialsProvider provides the credentials utilized to renew a session that has expired.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (username string, password string, err error)
+}
+
+// CredentialsFunc is an adapter to allow the use of ordinary functions as a CredentialsProvider.
+type CredentialsFunc func(ctx context.Context) (username string, password string, err error)
+
+// Credentials calls f(ctx).
+func (f CredentialsFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// StaticCredentials is a CredentialsProvider that always provides the same username and password.
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+// Credentials returns the username and password.
+func (s StaticCredentials) Credentials(context.Context) (string, string, error) {
+	return s.Username, s.Password, nil
+}
+
+// renewSession restores a newer session from the session store of the client or logs in again with its credentials. The
+// gen provided is the session generation observed when the failing request was made. If the session has been renewed
+// since then, by another goroutine for example, no login is performed.
+func (c *Client) renewSession(ctx context.Context, gen uint64) error {
+	c.renewMu.Lock()
+	defer c.renewMu.Unlock()
+
+	if c.sessionGen.Load() != gen {
+		return nil
+	}
+
+	// A session saved by another process is preferred over logging in again.
+	if c.SessionStore != nil {
+		session, err := c.SessionStore.LoadSession(ctx)
+		if err == nil && session.Nonce != c.SessionNonce() {
+			if err = c.SetSession(session); err == nil && c.ValidateSession(ctx) == nil {
+				return nil
+			}
+		}
+	}
+
+	if c.Credentials == nil {
+		return fmt.Errorf("no credentials available to renew the session")
+	}
+
+	username, password, err := c.Credentials.Credentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	return c.Login(ctx, username, password)
+}
+
+// withSessionRenewal calls fn and, if it fails because the session expired and the client has a credentials provider
+// or session store, renews the session and calls fn once more.
+func (c *Client) withSessionRenewal(ctx context.Context, fn func() error) error {
+	gen := c.sessionGen.Load()
+
+	err := fn()
+	if err == nil || (c.Credentials == nil && c.SessionStore == nil) || !errors.Is(err, ErrSessionExpired) {
+		return err
+	}
+
+	c.logger().InfoContext(ctx, "session expired, renewing", c.errAttr(err))
+	reportProgress(ctx, Progress{Phase: PhaseAuthenticating})
+	if renewErr := c.renewSession(ctx, gen); renewErr != nil {
+		c.logger().ErrorContext(ctx, "session renewal failed", c.errAttr(renewErr))
+		return fmt.Errorf("failed to renew expired session: %w: %w", renewErr, err)
+	}
+
+	return fn()
+}
@@ -0,0 +1,85 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// withCredentials enables automatic session renewal with the default credentials of the fake server.
+func withCredentials(opts *gocronometer.ClientOptions) {
+	opts.Credentials = gocronometer.StaticCredentials{
+		Username: gocronometertest.DefaultUsername,
+		Password: gocronometertest.DefaultPassword,
+	}
+}
+
+func TestClient_SessionRenewal(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+	srv.ExpireSessions()
+
+	if _, err := client.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export after the session expired: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 2 {
+		t.Fatalf("expected 2 logins but found %d", n)
+	}
+}
+
+func TestClient_SessionRenewal_ExportRedirect(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailExpiredSession, 1)
+
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export after being redirected to login: %s", err)
+	}
+}
+
+func TestClient_SessionRenewal_OnlyOnce(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+	srv.ExpireSessions()
+	srv.SetCredentials(gocronometertest.DefaultUsername, "changed")
+
+	_, err := client.ExportServings(context.Background(), time.Now(), time.Now())
+	if !errors.Is(err, gocronometer.ErrInvalidCredentials) || !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected both the renewal and expiry errors but received %v", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 2 {
+		t.Fatalf("expected 2 logins but found %d", n)
+	}
+}
+
+func TestClient_SessionRenewal_Concurrent(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+	srv.ExpireSessions()
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GenerateAuthToken(context.Background())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("failed to generate token after the session expired: %s", err)
+		}
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 2 {
+		t.Fatalf("expected a single renewal login but found %d logins", n)
+	}
+}
@@ -0,0 +1,169 @@
+package gocronometer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail transiently are retried. Requests are retried with an exponential
+// backoff until they succeed, fail with an error that is not retryable or MaxAttempts is reached. The login request
+// itself is never retried as it consumes the anticsrf value and carries the credentials.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first. Values less than 2 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the time waited before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the time waited between attempts. Retry-After values provided by the server are honored even if
+	// they exceed it.
+	MaxBackoff time.Duration
+
+	// Multiplier is applied to the backoff after every attempt. Values less than 1 are treated as 2.
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, of each backoff that is randomized.
+	Jitter float64
+
+	// RetryableStatusCodes are the response status codes that are retried.
+	RetryableStatusCodes []int
+
+	// RetryableError reports if an error returned while executing a request is retried. If nil all errors except
+	// context cancellation are retried.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns a policy of up to 4 attempts starting with a 500ms backoff, retrying connection errors
+// and the 429, 502, 503 and 504 status codes.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// retryable reports if the result of an attempt should be retried.
+func (p *RetryPolicy) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if p.RetryableError != nil {
+			return p.RetryableError(err)
+		}
+		return true
+	}
+
+	return slices.Contains(p.RetryableStatusCodes, resp.StatusCode)
+}
+
+// backoff returns the time to wait before the attempt provided, counted from 1 for the first retry.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := min(p.Jitter, 1)
+		backoff -= backoff * jitter * rand.Float64()
+	}
+
+	return time.Duration(backoff)
+}
+
+// retryAfter parses the Retry-After header of resp returning false if it is not present or invalid.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// do executes the request built by newRequest with the retry policy of the client. The attempt, counted from 0, is
+// provided to newRequest so a new request is built for every attempt. Errors returned by newRequest are never retried.
+// The response of the final attempt is returned even if it has a retryable status code.
+func (c *Client) do(ctx context.Context, newRequest func(attempt int) (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest(attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+
+		p := c.RetryPolicy
+		if p == nil || attempt+1 >= p.MaxAttempts || !p.retryable(resp, err) {
+			return resp, err
+		}
+
+		wait, ok := retryAfter(resp)
+		if !ok {
+			wait = p.backoff(attempt + 1)
+		}
+
+		attrs := []any{
+			slog.String("op", OperationFromContext(ctx)),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("wait", wait),
+		}
+		if err != nil {
+			attrs = append(attrs, c.errAttr(err))
+		} else {
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		}
+		c.logger().WarnContext(ctx, "retrying request", attrs...)
+
+		if resp != nil {
+			closeAndExhaustReader(resp.Body)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
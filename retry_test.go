@@ -0,0 +1,98 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// withRetryPolicy returns the configuration of a test client retrying with the policy provided.
+func withRetryPolicy(policy *gocronometer.RetryPolicy) func(opts *gocronometer.ClientOptions) {
+	return func(opts *gocronometer.ClientOptions) {
+		opts.RetryPolicy = policy
+	}
+}
+
+func TestClient_Retry_Export(t *testing.T) {
+	srv, client := loggedInClient(t, withRetryPolicy(gocronometer.DefaultRetryPolicy()))
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailUnavailable, 2)
+
+	if _, err := client.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export with retries: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 3 {
+		t.Fatalf("expected 3 export attempts but found %d", n)
+	}
+
+	// The cached token is reused by every attempt.
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 1 {
+		t.Fatalf("expected 1 token to be generated but found %d", n)
+	}
+}
+
+func TestClient_Retry_GWT(t *testing.T) {
+	policy := gocronometer.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.RetryableStatusCodes = []int{http.StatusInternalServerError}
+
+	srv, client := loggedInClient(t, withRetryPolicy(policy))
+	srv.InjectFailure(gocronometertest.EndpointGWTGenerateToken, gocronometertest.FailInternalError, 1)
+
+	if _, err := client.GenerateAuthToken(context.Background()); err != nil {
+		t.Fatalf("failed to generate token with retries: %s", err)
+	}
+}
+
+func TestClient_Retry_MaxAttempts(t *testing.T) {
+	srv, client := loggedInClient(t, withRetryPolicy(gocronometer.DefaultRetryPolicy()))
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailUnavailable, 0)
+
+	_, err := client.ExportNotes(context.Background(), time.Now(), time.Now())
+
+	var statusErr *gocronometer.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503 StatusError but received %v", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 4 {
+		t.Fatalf("expected 4 export attempts but found %d", n)
+	}
+}
+
+func TestClient_Retry_BadCredentials(t *testing.T) {
+	srv := newTestServer(t)
+
+	opts := srv.ClientOptions()
+	opts.RetryPolicy = gocronometer.DefaultRetryPolicy()
+	client := gocronometer.NewClient(opts)
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, "BAD"); !errors.Is(err, gocronometer.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials but received %v", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 1 {
+		t.Fatalf("expected a single login attempt but found %d", n)
+	}
+}
+
+func TestClient_Retry_ContextCanceled(t *testing.T) {
+	policy := gocronometer.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.RetryableStatusCodes = []int{http.StatusInternalServerError}
+
+	srv, client := loggedInClient(t, withRetryPolicy(policy))
+	srv.InjectFailure(gocronometertest.EndpointExport, gocronometertest.FailInternalError, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.ExportNotes(ctx, time.Now(), time.Now()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context deadline to end the retries but received %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package gocronometer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrNoSession is returned by a SessionStore when no session has been saved.
+var ErrNoSession = errors.New("no session saved")
+
+// SessionCookie is a cookie of a session along with the URL it was found for and the path it is scoped to. The cookie
+// jar of the client only exposes the names and values of its cookies, so the path is the shortest endpoint path the
+// cookie was found for.
+type SessionCookie struct {
+	URL   string `json:"url"`
+	Path  string `json:"path,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Session is the serializable state of an authenticated client. It is produced by Client.Session and applied with
+// Client.SetSession.
+type Session struct {
+	Nonce   string          `json:"nonce"`
+	UserID  string          `json:"userId"`
+	Cookies []SessionCookie `json:"cookies"`
+
+	GWTContentType string `json:"gwtContentType"`
+	GWTModuleBase  string `json:"gwtModuleBase"`
+	GWTPermutation string `json:"gwtPermutation"`
+	GWTHeader      string `json:"gwtHeader"`
+
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// SessionStore persists sessions so they can be shared between runs or processes.
+type SessionStore interface {
+	// LoadSession returns the stored session or an error matching ErrNoSession if none is stored.
+	LoadSession(ctx context.Context) (*Session, error)
+
+	// SaveSession stores the session replacing any previously stored.
+	SaveSession(ctx context.Context, session *Session) error
+}
+
+// FileSessionStore is a SessionStore that keeps the session as JSON in a single file. The file is replaced atomically
+// so multiple processes may share it.
+type FileSessionStore struct {
+	Path string
+}
+
+// LoadSession reads the session from the file.
+func (f FileSessionStore) LoadSession(_ context.Context) (*Session, error) {
+	file, err := os.Open(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session file: %w", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	return readSession(file)
+}
+
+// SaveSession writes the session to a temporary file that then replaces the session file.
+func (f FileSessionStore) SaveSession(_ context.Context, session *Session) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary session file: %w", err)
+	}
+	//noinspection GoUnhandledErrorResult
+	defer os.Remove(tmp.Name())
+
+	if err = writeSession(tmp, session); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary session file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+
+	return nil
+}
+
+// readSession decodes a session from r.
+func readSession(r io.Reader) (*Session, error) {
+	var session Session
+	if err := json.NewDecoder(r).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// writeSession encodes the session to w.
+func writeSession(w io.Writer, session *Session) error {
+	if err := json.NewEncoder(w).Encode(session); err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	return nil
+}
+
+// sessionURLs returns the root of the host of every endpoint of the client along with the endpoints themselves,
+// ordered by the length of their path so broader paths come first.
+func (c *Client) sessionURLs() []*url.URL {
+	seen := make(map[string]bool)
+	urls := make([]*url.URL, 0, 10)
+
+	add := func(u *url.URL) {
+		if seen[u.String()] {
+			return
+		}
+		seen[u.String()] = true
+		urls = append(urls, u)
+	}
+
+	st := c.state()
+	for _, raw := range []string{c.HTMLLoginURL, c.APILoginURL, c.GWTBaseURL, c.APIExportURL, st.gwtModuleBase} {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		add(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"})
+		add(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path})
+	}
+
+	sort.SliceStable(urls, func(i, j int) bool { return len(urls[i].Path) < len(urls[j].Path) })
+
+	return urls
+}
+
+// Session returns a snapshot of the current session of the client.
+func (c *Client) Session() *Session {
+	st := c.state()
+	session := &Session{
+		Nonce:          st.nonce,
+		UserID:         st.userID,
+		GWTContentType: st.gwtContentType,
+		GWTModuleBase:  st.gwtModuleBase,
+		GWTPermutation: st.gwtPermutation,
+		GWTHeader:      st.gwtHeader,
+		SavedAt:        time.Now(),
+	}
+
+	if c.HTTPClient.Jar == nil {
+		return session
+	}
+
+	// A cookie is recorded with the first, and so broadest, path it is found for.
+	seen := make(map[string]bool)
+	for _, u := range c.sessionURLs() {
+		for _, cookie := range c.HTTPClient.Jar.Cookies(u) {
+			key := u.Host + "\x00" + cookie.Name + "\x00" + cookie.Value
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			session.Cookies = append(session.Cookies,
+				SessionCookie{URL: u.String(), Path: u.Path, Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	return session
+}
+
+// SetSession replaces the session of the client with the one provided. The GWT values of the session are only applied
+// when set. The session is not validated, ValidateSession should be called to confirm it is still alive.
+func (c *Client) SetSession(session *Session) error {
+	if c.HTTPClient.Jar == nil {
+		return fmt.Errorf("http client has no cookie jar to restore the session into")
+	}
+
+	for _, sc := range session.Cookies {
+		u, err := url.Parse(sc.URL)
+		if err != nil {
+			return fmt.Errorf("failed to parse session cookie url: %w", err)
+		}
+		path := sc.Path
+		if path == "" {
+			path = "/"
+		}
+		c.HTTPClient.Jar.SetCookies(u, []*http.Cookie{{Name: sc.Name, Value: sc.Value, Path: path}})
+	}
+
+	c.secrets.pin(secretNonce, session.Nonce)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Nonce = session.Nonce
+	c.UserID = session.UserID
+
+	if session.GWTContentType != "" {
+		c.GWTContentType = session.GWTContentType
+	}
+	if session.GWTModuleBase != "" {
+		c.GWTModuleBase = session.GWTModuleBase
+	}
+	if session.GWTPermutation != "" {
+		c.GWTPermutation = session.GWTPermutation
+	}
+	if session.GWTHeader != "" {
+		c.GWTHeader = session.GWTHeader
+	}
+
+	c.sessionGen.Add(1)
+
+	return nil
+}
+
+// LoginWithSession logs in with the cookies of an existing session, such as those copied from a browser by a user who
+// signs in with Google or Apple and has no password. The cookies must include the sesnonce cookie. They are seeded into
+// the cookie jar for every endpoint of the client, the session is authenticated with the GWT API to obtain the user ID
+// and an export token is generated to confirm it is alive. An error matching ErrSessionExpired is returned if it is
+// not. If the client has a session store the session is saved to it. The session cannot be renewed with a password, so
+// once it expires a new one must be provided or restored from the session store.
+func (c *Client) LoginWithSession(ctx context.Context, cookies []*http.Cookie) error {
+	if c.HTTPClient.Jar == nil {
+		return fmt.Errorf("http client has no cookie jar to seed the session into")
+	}
+
+	nonce := ""
+	values := make([]string, 0, len(cookies))
+	seeded := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie.Name == "sesnonce" {
+			nonce = cookie.Value
+		}
+		values = append(values, cookie.Value)
+
+		cp := *cookie
+		if cp.Path == "" {
+			cp.Path = "/"
+		}
+		seeded = append(seeded, &cp)
+	}
+	c.secrets.pin(secretSessionCookies, values...)
+	if nonce == "" {
+		return fmt.Errorf("%w: no sesnonce cookie was provided", ErrSessionExpired)
+	}
+
+	for _, u := range c.sessionURLs() {
+		c.HTTPClient.Jar.SetCookies(u, seeded)
+	}
+
+	c.dropExportToken()
+	reportProgress(ctx, Progress{Phase: PhaseAuthenticating})
+
+	if err := c.gwtAuthenticate(ctx, nonce); err != nil {
+		return fmt.Errorf("failed to authenticate session with GWT: %w", err)
+	}
+	c.sessionGen.Add(1)
+
+	if _, err := c.exportToken(ctx); err != nil {
+		return fmt.Errorf("failed to validate session: %w", err)
+	}
+
+	c.logger().InfoContext(ctx, "logged in with session", slog.String("userid", c.SessionUserID()))
+
+	if c.SessionStore != nil {
+		if err := c.SaveSessionTo(ctx, c.SessionStore); err != nil {
+			return fmt.Errorf("logged in but failed to save session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// LoginWithNonce logs in with the value of the sesnonce cookie of an existing session. See LoginWithSession.
+func (c *Client) LoginWithNonce(ctx context.Context, nonce string) error {
+	return c.LoginWithSession(ctx, []*http.Cookie{{Name: "sesnonce", Value: nonce}})
+}
+
+// SaveSession writes the current session of the client to w as JSON. The output contains the session cookies and
+// should be protected like a password.
+func (c *Client) SaveSession(w io.Writer) error {
+	return writeSession(w, c.Session())
+}
+
+// RestoreSession reads a session written by SaveSession from r and applies it to the client. The session is not
+// validated, ValidateSession should be called to confirm it is still alive.
+func (c *Client) RestoreSession(r io.Reader) error {
+	session, err := readSession(r)
+	if err != nil {
+		return err
+	}
+
+	return c.SetSession(session)
+}
+
+// ValidateSession confirms the session of the client is still alive by authenticating with the GWT API. An error
+// matching ErrSessionExpired is returned if it is not.
+func (c *Client) ValidateSession(ctx context.Context) error {
+	st := c.state()
+	if st.nonce == "" {
+		return fmt.Errorf("%w: client has no session", ErrSessionExpired)
+	}
+
+	if err := c.GWTAuthenticate(ctx); err != nil {
+		return fmt.Errorf("failed to validate session: %w", err)
+	}
+
+	if userID := c.SessionUserID(); st.userID != "" && userID != st.userID {
+		return fmt.Errorf("%w: session belongs to user %s rather than %s", ErrSessionExpired, userID, st.userID)
+	}
+
+	return nil
+}
+
+// RestoreSessionFrom loads the session from the store, applies it to the client and validates it.
+func (c *Client) RestoreSessionFrom(ctx context.Context, store SessionStore) error {
+	session, err := store.LoadSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = c.SetSession(session); err != nil {
+		return err
+	}
+
+	return c.ValidateSession(ctx)
+}
+
+// SaveSessionTo saves the current session of the client to the store.
+func (c *Client) SaveSessionTo(ctx context.Context, store SessionStore) error {
+	return store.SaveSession(ctx, c.Session())
+}
@@ -0,0 +1,213 @@
+package gocronometer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_SaveSession_RestoreSession(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	buf := bytes.Buffer{}
+	if err := client.SaveSession(&buf); err != nil {
+		t.Fatalf("failed to save session: %s", err)
+	}
+
+	restored := srv.NewClient()
+	if err := restored.RestoreSession(&buf); err != nil {
+		t.Fatalf("failed to restore session: %s", err)
+	}
+
+	if restored.SessionNonce() != client.SessionNonce() || restored.SessionUserID() != client.SessionUserID() {
+		t.Fatalf("restored session did not match the saved session")
+	}
+
+	if err := restored.ValidateSession(context.Background()); err != nil {
+		t.Fatalf("restored session was not valid: %s", err)
+	}
+
+	if _, err := restored.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export with restored session: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 1 {
+		t.Fatalf("expected a single login but found %d", n)
+	}
+}
+
+func TestClient_ValidateSession_Expired(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+	srv.ExpireSessions()
+
+	if err := client.ValidateSession(context.Background()); !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired but received %v", err)
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	srv := newTestServer(t)
+	store := gocronometer.FileSessionStore{Path: filepath.Join(t.TempDir(), "session.json")}
+
+	if _, err := store.LoadSession(context.Background()); !errors.Is(err, gocronometer.ErrNoSession) {
+		t.Fatalf("expected ErrNoSession but received %v", err)
+	}
+
+	opts := srv.ClientOptions()
+	opts.SessionStore = store
+
+	first := gocronometer.NewClient(opts)
+	if err := first.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	second := gocronometer.NewClient(opts)
+	if err := second.RestoreSessionFrom(context.Background(), store); err != nil {
+		t.Fatalf("failed to restore session from store: %s", err)
+	}
+
+	if second.SessionUserID() != first.SessionUserID() {
+		t.Fatalf("expected user id %s but found %s", first.SessionUserID(), second.SessionUserID())
+	}
+}
+
+func TestClient_SessionRenewal_FromStore(t *testing.T) {
+	srv := newTestServer(t)
+	store := gocronometer.FileSessionStore{Path: filepath.Join(t.TempDir(), "session.json")}
+
+	opts := srv.ClientOptions()
+	opts.SessionStore = store
+
+	stale := gocronometer.NewClient(opts)
+	if err := stale.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	// Another process logs in after the first session expires and saves its session to the shared store.
+	srv.ExpireSessions()
+	fresh := gocronometer.NewClient(opts)
+	if err := fresh.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	if _, err := stale.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export after renewing from the store: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 2 {
+		t.Fatalf("expected the stored session to be used rather than a new login but found %d logins", n)
+	}
+}
+
+func TestClient_LoginWithSession(t *testing.T) {
+	srv := newTestServer(t)
+	store := gocronometer.FileSessionStore{Path: filepath.Join(t.TempDir(), "session.json")}
+
+	opts := srv.ClientOptions()
+	opts.SessionStore = store
+	client := gocronometer.NewClient(opts)
+
+	cookies := []*http.Cookie{{Name: "sesnonce", Value: srv.NewSession()}, {Name: "other", Value: "value"}}
+	if err := client.LoginWithSession(context.Background(), cookies); err != nil {
+		t.Fatalf("failed to login with session: %s", err)
+	}
+
+	if client.SessionUserID() == "" {
+		t.Fatalf("expected the user id to be obtained")
+	}
+
+	if _, err := client.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export with session: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 0 {
+		t.Fatalf("expected no password login but found %d", n)
+	}
+
+	saved, err := store.LoadSession(context.Background())
+	if err != nil {
+		t.Fatalf("failed to load saved session: %s", err)
+	}
+	if saved.Nonce != cookies[0].Value || saved.UserID != client.SessionUserID() {
+		t.Fatalf("saved session did not match the session logged in with")
+	}
+}
+
+func TestClient_LoginWithNonce_Expired(t *testing.T) {
+	srv := newTestServer(t)
+
+	err := srv.NewClient().LoginWithNonce(context.Background(), "0123456789abcdef")
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired but received %v", err)
+	}
+
+	err = srv.NewClient().LoginWithSession(context.Background(), []*http.Cookie{{Name: "other", Value: "value"}})
+	if !errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected ErrSessionExpired without a sesnonce cookie but received %v", err)
+	}
+}
+
+func TestClient_LoginWithNonce_RenewalFromStore(t *testing.T) {
+	srv := newTestServer(t)
+	store := gocronometer.FileSessionStore{Path: filepath.Join(t.TempDir(), "session.json")}
+
+	opts := srv.ClientOptions()
+	opts.SessionStore = store
+
+	stale := gocronometer.NewClient(opts)
+	if err := stale.LoginWithNonce(context.Background(), srv.NewSession()); err != nil {
+		t.Fatalf("failed to login with nonce: %s", err)
+	}
+
+	// The user copies a new session cookie into another process once the first expires.
+	srv.ExpireSessions()
+	if err := gocronometer.NewClient(opts).LoginWithNonce(context.Background(), srv.NewSession()); err != nil {
+		t.Fatalf("failed to login with nonce: %s", err)
+	}
+
+	if _, err := stale.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export after renewing from the store: %s", err)
+	}
+}
+
+func TestClient_SaveSession_CookiePaths(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	gwtURL, _ := url.Parse(client.GWTBaseURL)
+	rootURL, _ := url.Parse(srv.URL + "/")
+	client.HTTPClient.Jar.SetCookies(gwtURL, []*http.Cookie{{Name: "gwtscope", Value: "scoped", Path: "/cronometer/"}})
+
+	buf := bytes.Buffer{}
+	if err := client.SaveSession(&buf); err != nil {
+		t.Fatalf("failed to save session: %s", err)
+	}
+
+	restored := srv.NewClient()
+	if err := restored.RestoreSession(&buf); err != nil {
+		t.Fatalf("failed to restore session: %s", err)
+	}
+
+	has := func(u *url.URL, name string) bool {
+		for _, cookie := range restored.HTTPClient.Jar.Cookies(u) {
+			if cookie.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !has(gwtURL, "gwtscope") || has(rootURL, "gwtscope") {
+		t.Fatalf("expected the cookie to be restored scoped to /cronometer/")
+	}
+	if !has(rootURL, "sesnonce") {
+		t.Fatalf("expected the sesnonce cookie to be restored for the root")
+	}
+}
@@ -0,0 +1,71 @@
+package gocronometer
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// clientState is a consistent snapshot of the session and GWT values of a client. Requests are built from a single
+// snapshot so a concurrent login or discovery cannot mix the values of two sessions in one request.
+type clientState struct {
+	nonce          string
+	userID         string
+	gwtContentType string
+	gwtModuleBase  string
+	gwtPermutation string
+	gwtHeader      string
+}
+
+// state returns a snapshot of the session and GWT values of the client.
+func (c *Client) state() clientState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return clientState{
+		nonce:          c.Nonce,
+		userID:         c.UserID,
+		gwtContentType: c.GWTContentType,
+		gwtModuleBase:  c.GWTModuleBase,
+		gwtPermutation: c.GWTPermutation,
+		gwtHeader:      c.GWTHeader,
+	}
+}
+
+// setSession replaces the nonce and user ID of the client.
+func (c *Client) setSession(nonce string, userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Nonce = nonce
+	c.UserID = userID
+}
+
+// SessionNonce returns the sesnonce of the current session or an empty string if the client is not logged in.
+func (c *Client) SessionNonce() string {
+	return c.state().nonce
+}
+
+// SessionUserID returns the user ID of the current session or an empty string if the client is not logged in.
+func (c *Client) SessionUserID() string {
+	return c.state().userID
+}
+
+// newGWTRequest creates a new http request with the GWT headers of the snapshot.
+func (s clientState) newGWTRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("content-type", s.gwtContentType)
+	req.Header.Add("x-gwt-module-base", s.gwtModuleBase)
+	req.Header.Add("x-gwt-permutation", s.gwtPermutation)
+
+	return req, nil
+}
+
+// gwtRequestBody encodes the call into the body of a GWT request with the module base and header of the snapshot.
+func (s clientState) gwtRequestBody(call GWTCall) string {
+	return call.Encode(s.gwtModuleBase, s.gwtHeader)
+}
@@ -0,0 +1,119 @@
+package gocronometer_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_ConcurrentExports(t *testing.T) {
+	srv, client := loggedInClient(t, withCredentials)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 10, 0, 0, 0, 0, time.UTC)
+	exports := []func(context.Context, time.Time, time.Time) (string, error){
+		client.ExportServings,
+		client.ExportBiometrics,
+		client.ExportExercises,
+		client.ExportNotes,
+		client.ExportDailyNutrition,
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(exports)*4)
+	for i := 0; i < 4; i++ {
+		for _, export := range exports {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := export(context.Background(), start, end); err != nil {
+					errs <- err
+				}
+			}()
+		}
+
+		// Expiring the sessions while exports are in flight forces renewals to race with them.
+		srv.ExpireSessions()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			_ = client.Session()
+			_ = client.SessionNonce()
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("failed to export concurrently: %s", err)
+	}
+
+	if client.SessionNonce() == "" || client.SessionUserID() != strconv.Itoa(gocronometertest.DefaultUserID) {
+		t.Fatalf("expected a logged in session but found nonce %q and user %q", client.SessionNonce(), client.SessionUserID())
+	}
+}
+
+func TestClient_ConcurrentLogin(t *testing.T) {
+	srv := newTestServer(t)
+	client := srv.NewClient()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+				t.Errorf("failed to login: %s", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = client.Session()
+		}()
+	}
+	wg.Wait()
+
+	session := client.Session()
+	if session.Nonce == "" || session.UserID == "" {
+		t.Fatalf("expected a session after concurrent logins but found %+v", session)
+	}
+}
+
+func TestClient_Login_PublishesSessionOnceAuthenticated(t *testing.T) {
+	var client *gocronometer.Client
+	var during *gocronometer.Session
+
+	srv, client := loggedInClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.Hooks = []gocronometer.Hooks{{
+			BeforeRequest: func(_ context.Context, op string, _ *http.Request) {
+				if op == gocronometer.OpGWTAuthenticate && client != nil {
+					during = client.Session()
+				}
+			},
+		}}
+	})
+	before := client.Session()
+
+	// The new nonce is not published until GWT authentication has provided the user ID of the new session.
+	srv.SetUserID(4242)
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	if during.Nonce != before.Nonce || during.UserID != before.UserID {
+		t.Fatalf("expected the previous session during authentication but found nonce %q and user %q",
+			during.Nonce, during.UserID)
+	}
+	if after := client.Session(); after.Nonce == before.Nonce || after.UserID != "4242" {
+		t.Fatalf("expected the new session after login but found nonce %q and user %q", after.Nonce, after.UserID)
+	}
+}
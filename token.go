@@ -0,0 +1,87 @@
+package gocronometer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultTokenLifetime is the lifetime requested for export tokens unless another is provided on ClientOptions. It
+// matches the lifetime requested by the web app.
+const DefaultTokenLifetime = time.Hour
+
+// tokenRefreshMargin is how long before its expiry a cached export token is replaced. Lifetimes shorter than ten
+// times the margin are refreshed after nine tenths of the lifetime instead.
+const tokenRefreshMargin = time.Minute
+
+// AuthScope is the scope requested for an export token. It is sent as the ordinal of the AuthScope enum of the GWT
+// API.
+type AuthScope int
+
+// AuthScopeExport is the scope the web app requests for data exports.
+const AuthScopeExport AuthScope = 2
+
+// exportToken is a cached export token.
+type exportToken struct {
+	value   string
+	expires time.Time
+
+	// gen is the session generation the token was generated under. Tokens of an older session are never reused.
+	gen uint64
+}
+
+// valid returns true if the token belongs to the session generation gen and is not about to expire.
+func (t exportToken) valid(gen uint64, lifetime time.Duration) bool {
+	margin := tokenRefreshMargin
+	if lifetime < 10*margin {
+		margin = lifetime / 10
+	}
+	return t.value != "" && t.gen == gen && time.Now().Before(t.expires.Add(-margin))
+}
+
+// tokenLifetime returns the lifetime requested for export tokens.
+func (c *Client) tokenLifetime() time.Duration {
+	if c.TokenLifetime <= 0 {
+		return DefaultTokenLifetime
+	}
+	return c.TokenLifetime
+}
+
+// exportToken returns the cached export token or generates a new one if there is none or it is about to expire.
+// Concurrent callers wait for a single generation.
+func (c *Client) exportToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	gen := c.sessionGen.Load()
+	if c.token.valid(gen, c.tokenLifetime()) {
+		c.logger().DebugContext(ctx, "export token reused")
+		return c.token.value, nil
+	}
+
+	reportProgress(ctx, Progress{Phase: PhaseToken})
+
+	// The expiry is measured from before the request so the token never outlives the server side expiry.
+	issued := time.Now()
+	token, err := c.generateAuthToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = exportToken{value: token, expires: issued.Add(c.tokenLifetime()), gen: gen}
+	c.logger().DebugContext(ctx, "export token cached", slog.Time("expires", c.token.expires))
+
+	return token, nil
+}
+
+// dropExportToken removes the cached export token so the next export generates a new one. It returns true if a token
+// was cached.
+func (c *Client) dropExportToken() bool {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	cached := c.token.value != ""
+	c.token = exportToken{}
+
+	return cached
+}
@@ -0,0 +1,98 @@
+package gocronometer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+func TestClient_ExportToken_Reused(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.ExportServings(context.Background(), time.Now(), time.Now()); err != nil {
+			t.Fatalf("failed to export: %s", err)
+		}
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 1 {
+		t.Fatalf("expected 1 token to be generated but found %d", n)
+	}
+}
+
+func TestClient_ExportToken_Rejected(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	if _, err := client.ExportBiometrics(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	srv.ExpireTokens()
+
+	if _, err := client.ExportBiometrics(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export after the token was rejected: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 2 {
+		t.Fatalf("expected 2 tokens to be generated but found %d", n)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointLogin); n != 1 {
+		t.Fatalf("expected no additional login but found %d logins", n)
+	}
+}
+
+func TestClient_ExportToken_Refreshed(t *testing.T) {
+	// A one second lifetime is refreshed after 900ms.
+	srv, client := loggedInClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.TokenLifetime = time.Second
+	})
+
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	time.Sleep(950 * time.Millisecond)
+
+	if _, err := client.ExportNotes(context.Background(), time.Now(), time.Now()); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+
+	if n := srv.RequestCount(gocronometertest.EndpointGWTGenerateToken); n != 2 {
+		t.Fatalf("expected 2 tokens to be generated but found %d", n)
+	}
+	if n := srv.RequestCount(gocronometertest.EndpointExport); n != 2 {
+		t.Fatalf("expected the token to be refreshed before the export but found %d exports", n)
+	}
+}
+
+func TestClient_ExportToken_LifetimeAndScope(t *testing.T) {
+	srv, client := loggedInClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.TokenLifetime = 10 * time.Minute
+		opts.AuthScope = 3
+	})
+
+	if _, err := client.GenerateAuthToken(context.Background()); err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	want := gocronometertest.TokenRequest{Lifetime: 10 * time.Minute, Scope: 3}
+	if got := srv.LastTokenRequest(); got != want {
+		t.Fatalf("expected token request %+v but found %+v", want, got)
+	}
+}
+
+func TestClient_ExportToken_DefaultLifetimeAndScope(t *testing.T) {
+	srv, client := loggedInClient(t, nil)
+
+	if _, err := client.GenerateAuthToken(context.Background()); err != nil {
+		t.Fatalf("failed to generate token: %s", err)
+	}
+
+	want := gocronometertest.TokenRequest{Lifetime: gocronometer.DefaultTokenLifetime, Scope: gocronometer.AuthScopeExport}
+	if got := srv.LastTokenRequest(); got != want {
+		t.Fatalf("expected token request %+v but found %+v", want, got)
+	}
+}
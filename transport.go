@@ -0,0 +1,107 @@
+package gocronometer
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// The following are the operation names provided to hooks. Export operations are named "export." followed by the
+// generate value of the export, such as "export.servings".
+const (
+	OpLoginPage        = "login.page"
+	OpLogin            = "login"
+	OpTwoFactorPage    = "login.2fa.page"
+	OpTwoFactor        = "login.2fa"
+	OpGWTAuthenticate  = "gwt.authenticate"
+	OpGWTGenerateToken = "gwt.generateAuthorizationToken"
+	OpGWTLogout        = "gwt.logout"
+	OpGWTDiscovery     = "gwt.discovery"
+	OpExportPrefix     = "export."
+)
+
+// operationKey is the context key of the operation name.
+type operationKey struct{}
+
+// withOperation returns a context carrying the operation name op.
+func withOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext returns the operation name carried by ctx or an empty string if there is none. The context of
+// every request made by the client carries the operation it belongs to.
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// clientTransport is the http.RoundTripper of the client. It calls the hooks of the client around every request and
+// waits on the rate limiter of the client before executing the request with the base transport.
+type clientTransport struct {
+	client *Client
+	base   http.RoundTripper
+}
+
+// RoundTrip executes the request calling the hooks and waiting on the rate limiter of the client.
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	op := OperationFromContext(ctx)
+
+	for _, h := range t.client.Hooks {
+		if h.BeforeRequest != nil {
+			h.BeforeRequest(ctx, op, req)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.roundTrip(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		for _, h := range t.client.Hooks {
+			if h.OnError != nil {
+				h.OnError(ctx, op, req, err, elapsed)
+			}
+		}
+		return nil, err
+	}
+
+	for _, h := range t.client.Hooks {
+		if h.AfterResponse != nil {
+			h.AfterResponse(ctx, op, req, resp, elapsed)
+		}
+	}
+
+	return resp, nil
+}
+
+// roundTrip waits on the rate limiter and executes the request with the base transport.
+func (t *clientTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if limiter := t.client.RateLimiter; limiter != nil {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(req)
+}
+
+// Hooks are functions called around every request made through the HTTP client of the client. Any of the functions may
+// be nil. The op provided is the operation the request belongs to, such as OpLogin or "export.servings". Hooks must not
+// consume the request body, and a hook that reads the response body must replace it.
+type Hooks struct {
+	// BeforeRequest is called before the request is executed.
+	BeforeRequest func(ctx context.Context, op string, req *http.Request)
+
+	// AfterResponse is called after a response has been received along with the time elapsed since the request was
+	// started.
+	AfterResponse func(ctx context.Context, op string, req *http.Request, resp *http.Response, elapsed time.Duration)
+
+	// OnError is called when the request fails without a response.
+	OnError func(ctx context.Context, op string, req *http.Request, err error, elapsed time.Duration)
+}
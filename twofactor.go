@@ -0,0 +1,137 @@
+package gocronometer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TwoFactorFunc provides the code of the second factor of a login, for example by prompting the user for it.
+type TwoFactorFunc func(ctx context.Context) (string, error)
+
+// The following are the parameters of the TOTP codes generated by GenerateTOTP. They are the defaults of RFC 6238,
+// which authenticator apps use.
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+)
+
+// TOTP returns a TwoFactorFunc that generates the current TOTP code of the base32 secret provided when the account was
+// set up for two-factor authentication. It allows logins to complete without a user present.
+func TOTP(secret string) TwoFactorFunc {
+	return func(context.Context) (string, error) {
+		return GenerateTOTP(secret, time.Now())
+	}
+}
+
+// GenerateTOTP generates the RFC 6238 TOTP code of the base32 secret for the time t. Codes are 6 digits and change
+// every 30 seconds. Spaces and padding in the secret are ignored and it is not case sensitive.
+func GenerateTOTP(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode TOTP secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(totpPeriod/time.Second)))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described by RFC 4226.
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, code%uint32(math.Pow10(totpDigits))), nil
+}
+
+// twoFactorRedirectRegex matches the redirect of a login response that leads to the second factor challenge.
+var twoFactorRedirectRegex = regexp.MustCompile(`(?i)2fa|two-?factor|mfa`)
+
+// twoFactorRequired returns true if the login response is a second factor challenge rather than a completed login.
+func (r LoginResponse) twoFactorRequired() bool {
+	return r.Error == "" && !r.Success && twoFactorRedirectRegex.MatchString(r.Redirect)
+}
+
+// completeTwoFactor completes a login that requires a second factor. The challenge page at redirect, relative to the
+// loginURL the password was posted to, provides a new anticsrf value that is posted back along with the code from the
+// TwoFactor function of the client. The nonce of the session is returned once the code is accepted.
+func (c *Client) completeTwoFactor(ctx context.Context, loginURL *url.URL, redirect string) (string, error) {
+	c.logger().InfoContext(ctx, "two-factor code required")
+
+	if c.TwoFactor == nil {
+		return "", fmt.Errorf("%w: no two-factor function is configured", ErrTwoFactorRequired)
+	}
+
+	challengeURL, err := loginURL.Parse(redirect)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to parse two-factor redirect: %w", ErrUnexpectedResponse, err)
+	}
+
+	code, err := c.TwoFactor(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to obtain two-factor code: %w", ErrTwoFactorRequired, err)
+	}
+	c.secrets.pin(secretTwoFactorCode, code)
+
+	antiCSRF, err := c.obtainAntiCSRF(ctx, OpTwoFactorPage, challengeURL.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve anti CSRF of two-factor page: %w", err)
+	}
+
+	// Building the code request.
+	formData := url.Values{}
+	formData.Set("anticsrf", antiCSRF)
+	formData.Set("code", code)
+
+	req, err := http.NewRequestWithContext(withOperation(ctx, OpTwoFactor), "POST", challengeURL.String(), strings.NewReader(formData.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed while building http request for two-factor code: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// The code is only valid once so the request is never retried.
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed while executing http request for two-factor code: %w", err)
+	}
+	defer closeAndExhaustReader(resp.Body)
+
+	if resp.StatusCode != 200 {
+		return "", &StatusError{Op: "two-factor code", StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read body of two-factor response: %w", err)
+	}
+
+	var codeResponse LoginResponse
+	if err = json.Unmarshal(body, &codeResponse); err != nil {
+		return "", fmt.Errorf("%w: failed to unmarshal two-factor response json: %w", ErrUnexpectedResponse, err)
+	}
+
+	if codeResponse.Error != "" {
+		c.logger().WarnContext(ctx, "two-factor code rejected", slog.String("message", codeResponse.Error))
+		return "", &TwoFactorError{Message: codeResponse.Error}
+	}
+
+	nonce := c.sesnonce(resp)
+	c.logger().DebugContext(ctx, "two-factor code accepted")
+
+	return nonce, nil
+}
@@ -0,0 +1,137 @@
+package gocronometer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/gocronometer"
+	"github.com/jrmycanady/gocronometer/gocronometertest"
+)
+
+// testTOTPSecret is the base32 encoding of the SHA1 secret of the RFC 6238 test vectors.
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTP(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, test := range tests {
+		code, err := gocronometer.GenerateTOTP(testTOTPSecret, time.Unix(test.unix, 0))
+		if err != nil {
+			t.Fatalf("failed to generate code for %d: %s", test.unix, err)
+		}
+		if code != test.code {
+			t.Errorf("expected code %s for %d but received %s", test.code, test.unix, code)
+		}
+	}
+
+	if _, err := gocronometer.GenerateTOTP("not base32!", time.Now()); err == nil {
+		t.Errorf("expected an error for an invalid secret")
+	}
+}
+
+// twoFactorClient returns a client of a fake server that requires the second factor of testTOTPSecret with the
+// two-factor function provided.
+func twoFactorClient(t *testing.T, twoFactor gocronometer.TwoFactorFunc) *gocronometer.Client {
+	srv, client := testClient(t, func(opts *gocronometer.ClientOptions) { opts.TwoFactor = twoFactor })
+	srv.SetTwoFactorSecret(testTOTPSecret)
+	return client
+}
+
+func TestClient_Login_TwoFactor(t *testing.T) {
+	client := twoFactorClient(t, gocronometer.TOTP(testTOTPSecret))
+
+	if err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword); err != nil {
+		t.Fatalf("failed to login: %s", err)
+	}
+
+	if _, err := client.ExportServings(context.Background(), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("failed to export after two-factor login: %s", err)
+	}
+}
+
+func TestClient_Login_TwoFactorRequiredError(t *testing.T) {
+	client := twoFactorClient(t, nil)
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrTwoFactorRequired) {
+		t.Fatalf("expected ErrTwoFactorRequired but received %v", err)
+	}
+	if errors.Is(err, gocronometer.ErrInvalidCredentials) {
+		t.Fatalf("expected the error not to be ErrInvalidCredentials but received %v", err)
+	}
+}
+
+func TestClient_Login_TwoFactorFuncError(t *testing.T) {
+	cause := errors.New("no code")
+	client := twoFactorClient(t, func(context.Context) (string, error) { return "", cause })
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrTwoFactorRequired) || !errors.Is(err, cause) {
+		t.Fatalf("expected ErrTwoFactorRequired wrapping the cause but received %v", err)
+	}
+}
+
+func TestClient_Login_InvalidTwoFactorCodeError(t *testing.T) {
+	client := twoFactorClient(t, func(context.Context) (string, error) { return "000000", nil })
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode but received %v", err)
+	}
+
+	var twoFactorErr *gocronometer.TwoFactorError
+	if !errors.As(err, &twoFactorErr) || twoFactorErr.Message == "" {
+		t.Fatalf("expected a TwoFactorError with a message but received %v", err)
+	}
+}
+
+func TestClient_Login_TwoFactorInvalidCredentialsError(t *testing.T) {
+	called := false
+	client := twoFactorClient(t, func(context.Context) (string, error) { called = true; return "000000", nil })
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, "BAD")
+	if !errors.Is(err, gocronometer.ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials but received %v", err)
+	}
+	if called {
+		t.Errorf("expected the two-factor function not to be called for a rejected password")
+	}
+}
+
+func TestClient_Login_UnknownRedirectError(t *testing.T) {
+	// A login that is neither completed nor a second factor challenge, such as a pending email verification.
+	_, client := testClient(t, func(opts *gocronometer.ClientOptions) {
+		opts.Hooks = []gocronometer.Hooks{{
+			AfterResponse: func(_ context.Context, op string, _ *http.Request, resp *http.Response, _ time.Duration) {
+				if op == gocronometer.OpLogin {
+					_ = resp.Body.Close()
+					resp.Header.Del("Set-Cookie")
+					resp.Body = io.NopCloser(strings.NewReader(`{"success":false,"redirect":"/verify-email"}`))
+				}
+			},
+		}}
+	})
+
+	err := client.Login(context.Background(), gocronometertest.DefaultUsername, gocronometertest.DefaultPassword)
+	if !errors.Is(err, gocronometer.ErrUnexpectedResponse) || !strings.Contains(err.Error(), "/verify-email") {
+		t.Fatalf("expected ErrUnexpectedResponse with the redirect but received %v", err)
+	}
+	if errors.Is(err, gocronometer.ErrSessionExpired) {
+		t.Fatalf("expected the error not to be ErrSessionExpired but received %v", err)
+	}
+}